@@ -3,6 +3,7 @@ module github.com/bitcoin-sv/go-templates
 go 1.24.3
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/bitcoinschema/go-sigma v0.1.2
 	github.com/bsv-blockchain/go-sdk v1.2.0
 	github.com/stretchr/testify v1.10.0
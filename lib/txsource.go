@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+var (
+	ErrTxNotFound = errors.New("transaction not found")
+	ErrNoTxSource = errors.New("transaction source not supplied")
+)
+
+// TxSource loads transactions by txid, e.g. from a node, an indexer or a local store
+type TxSource interface {
+	LoadTx(ctx context.Context, txid *chainhash.Hash) (*transaction.Transaction, error)
+}
+
+// MemoryTxSource is an in-memory TxSource. It is safe for concurrent use.
+type MemoryTxSource struct {
+	mu  sync.RWMutex
+	txs map[chainhash.Hash]*transaction.Transaction
+}
+
+// NewMemoryTxSource creates an in-memory TxSource holding txs
+func NewMemoryTxSource(txs ...*transaction.Transaction) *MemoryTxSource {
+	m := &MemoryTxSource{
+		txs: make(map[chainhash.Hash]*transaction.Transaction, len(txs)),
+	}
+	m.Add(txs...)
+	return m
+}
+
+// Add stores transactions, keyed by their txid
+func (m *MemoryTxSource) Add(txs ...*transaction.Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tx := range txs {
+		m.txs[*tx.TxID()] = tx
+	}
+}
+
+// LoadTx returns the transaction with the given txid, or ErrTxNotFound
+func (m *MemoryTxSource) LoadTx(_ context.Context, txid *chainhash.Hash) (*transaction.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if tx, ok := m.txs[*txid]; ok {
+		return tx, nil
+	}
+	return nil, ErrTxNotFound
+}
+
+// DirTxSource is a TxSource reading transactions from files in a directory,
+// each named by its txid with a .hex extension and holding the raw transaction
+// in hex, as in the testdata directories
+type DirTxSource string
+
+// LoadTx reads the transaction with the given txid, or returns ErrTxNotFound
+func (d DirTxSource) LoadTx(_ context.Context, txid *chainhash.Hash) (*transaction.Transaction, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), txid.String()+".hex"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrTxNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return transaction.NewTransactionFromHex(strings.TrimSpace(string(data)))
+}
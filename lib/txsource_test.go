@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+func testTx(t *testing.T, data string) *transaction.Transaction {
+	t.Helper()
+	s := &script.Script{}
+	if err := s.AppendPushData([]byte(data)); err != nil {
+		t.Fatalf("AppendPushData error: %v", err)
+	}
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+	return tx
+}
+
+func TestMemoryTxSource(t *testing.T) {
+	ctx := context.Background()
+	first, second := testTx(t, "first"), testTx(t, "second")
+	src := NewMemoryTxSource(first)
+	src.Add(second)
+
+	for _, tx := range []*transaction.Transaction{first, second} {
+		loaded, err := src.LoadTx(ctx, tx.TxID())
+		if err != nil || loaded != tx {
+			t.Errorf("LoadTx(%s) returned %v, %v", tx.TxID(), loaded, err)
+		}
+	}
+	if _, err := src.LoadTx(ctx, &chainhash.Hash{}); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("expected ErrTxNotFound, got %v", err)
+	}
+}
+
+func TestDirTxSource(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t, "stored")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, tx.TxID().String()+".hex"), []byte(tx.Hex()+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	loaded, err := DirTxSource(dir).LoadTx(ctx, tx.TxID())
+	if err != nil {
+		t.Fatalf("LoadTx error: %v", err)
+	}
+	if !loaded.TxID().Equal(*tx.TxID()) {
+		t.Errorf("txid mismatch: got %s, want %s", loaded.TxID(), tx.TxID())
+	}
+	if _, err := DirTxSource(dir).LoadTx(ctx, &chainhash.Hash{}); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("expected ErrTxNotFound, got %v", err)
+	}
+}
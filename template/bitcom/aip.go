@@ -1,25 +1,62 @@
 package bitcom
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"slices"
 	"strconv"
 	"unicode"
 
 	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 )
 
 // AIPPrefix is the bitcom protocol prefix for AIP
 const AIPPrefix = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
 
+// AIP signature algorithms
+const (
+	AIPAlgoBitcoinECDSA         = "BITCOIN_ECDSA"        // Backwards compatible label for BitcoinSignedMessage
+	AIPAlgoBitcoinSignedMessage = "BitcoinSignedMessage" // Current label, signed and verified identically
+	AIPAlgoPaymail              = "paymail"              // Address is a paymail handle resolved to public keys
+)
+
+var (
+	ErrUnsupportedAIPAlgorithm = errors.New("unsupported AIP algorithm")
+	ErrInvalidFieldIndex       = errors.New("invalid AIP field index")
+	ErrNoPubKeyResolver        = errors.New("public key resolver not supplied")
+	ErrAIPSignatureMismatch    = errors.New("AIP signature does not match")
+)
+
+// AIPStatus is the outcome of verifying an AIP signature
+type AIPStatus string
+
+const (
+	// AIPUnverified means the signature could not be checked, such as a paymail
+	// signature decoded without a public key resolver
+	AIPUnverified AIPStatus = "unverified"
+
+	// AIPVerified means the signature was checked and is valid
+	AIPVerified AIPStatus = "verified"
+
+	// AIPFailed means the signature was checked and is not valid
+	AIPFailed AIPStatus = "failed"
+)
+
 // AIP represents an AIP
 type AIP struct {
-	BitcomIndex  uint   `json:"ii,omitempty"` // Index of the AIP in the Bitcom transaction
-	Algorithm    string `json:"algorithm"`
-	Address      string `json:"address"`
-	Signature    []byte `json:"signature"`
-	FieldIndexes []int  `json:"fieldIndexes,omitempty"`
-	Valid        bool   `json:"valid,omitempty"`
+	BitcomIndex  uint      `json:"ii,omitempty"` // Index of the AIP in the Bitcom transaction
+	Algorithm    string    `json:"algorithm"`
+	Address      string    `json:"address"`
+	Signature    []byte    `json:"signature"`
+	FieldIndexes []int     `json:"fieldIndexes,omitempty"`
+	Valid        bool      `json:"valid,omitempty"`  // Status is AIPVerified
+	Status       AIPStatus `json:"status,omitempty"` // Outcome of the last verification
+	PubKey       string    `json:"pubkey,omitempty"` // Hex public key that matched, set by VerifyAIP
 }
 
 // DecodeAIP decodes the AIP data from the transaction script
@@ -33,63 +70,266 @@ func DecodeAIP(b *Bitcom) []*AIP {
 
 	for protoIdx, proto := range b.Protocols {
 		if proto.Protocol == AIPPrefix {
-			scr := script.NewFromBytes(proto.Script)
-			if scr == nil {
-				continue
+			if aip := decodeAIPAt(b, protoIdx); aip != nil {
+				aips = append(aips, aip)
 			}
+		}
+	}
 
-			// Parse script into chunks
-			chunks, err := scr.Chunks()
-			if err != nil || len(chunks) < 3 { // Need at least algorithm, address, and signature
-				continue
-			}
+	return aips
+}
 
-			aip := &AIP{
-				BitcomIndex: uint(protoIdx),
-			}
+// decodeAIPAt decodes and validates the AIP protocol at index protoIdx of the Bitcom.
+// Returns nil if the protocol is not AIP or is missing required fields.
+func decodeAIPAt(b *Bitcom, protoIdx int) *AIP {
+	proto := b.Protocols[protoIdx]
+	if proto.Protocol != AIPPrefix {
+		return nil
+	}
+	scr := script.NewFromBytes(proto.Script)
+	if scr == nil {
+		return nil
+	}
 
-			// Read ALGORITHM (first chunk)
-			if len(chunks) > 0 {
-				aip.Algorithm = string(chunks[0].Data)
-			} else {
-				continue
-			}
+	// Parse script into chunks
+	chunks, err := scr.Chunks()
+	if err != nil {
+		b.AddWarning(protoIdx, 0, "malformed AIP script: "+err.Error())
+		return nil
+	}
+	if len(chunks) < 3 { // Need at least algorithm, address, and signature
+		b.AddWarning(protoIdx, 0, "AIP requires an algorithm, address and signature")
+		return nil
+	}
 
-			// Read ADDRESS (second chunk)
-			if len(chunks) > 1 {
-				aip.Address = string(chunks[1].Data)
-			} else {
-				continue
-			}
+	aip := &AIP{
+		BitcomIndex: uint(protoIdx),
+		// Read ALGORITHM (first chunk)
+		Algorithm: string(chunks[0].Data),
+		// Read ADDRESS (second chunk)
+		Address: string(chunks[1].Data),
+		// Read SIGNATURE (third chunk)
+		Signature: chunks[2].Data,
+	}
 
-			// Read SIGNATURE (third chunk)
-			if len(chunks) > 2 {
-				// aip.Signature = base64.StdEncoding.EncodeToString(chunks[2].Data)
-				aip.Signature = chunks[2].Data
-			} else {
-				continue
-			}
+	// Read optional FIELD INDEXES (remaining chunks)
+	// If present, these indicate which fields were signed
+	for i := 3; i < len(chunks); i++ {
+		index, err := strconv.Atoi(string(chunks[i].Data))
+		if err != nil {
+			b.AddWarning(protoIdx, 0, fmt.Sprintf("AIP field index %q is not a number", chunks[i].Data))
+			break // Stop if we encounter non-numeric data
+		}
+		aip.FieldIndexes = append(aip.FieldIndexes, index)
+	}
 
-			// Read optional FIELD INDEXES (remaining chunks)
-			// If present, these indicate which fields were signed
-			for i := 3; i < len(chunks); i++ {
-				index, err := strconv.Atoi(string(chunks[i].Data))
-				if err != nil {
-					break // Stop if we encounter non-numeric data
-				}
-				aip.FieldIndexes = append(aip.FieldIndexes, index)
-			}
+	validateAip(aip, b.Protocols[:protoIdx])
+	return aip
+}
+
+// SignAIP signs the protocols of b with key and returns the unappended AIP.
+// If fieldIndexes is empty every field is signed, otherwise only the fields at
+// those indexes are, exactly as verified when the AIP is decoded.
+// An empty algorithm defaults to AIPAlgoBitcoinECDSA.
+func SignAIP(b *Bitcom, key *ec.PrivateKey, algorithm string, fieldIndexes ...int) (*AIP, error) {
+	if key == nil {
+		return nil, ErrNoPrivateKey
+	}
+	if b == nil || len(b.Protocols) == 0 {
+		return nil, ErrNoProtocols
+	}
+	switch algorithm {
+	case "":
+		algorithm = AIPAlgoBitcoinECDSA
+	case AIPAlgoBitcoinECDSA, AIPAlgoBitcoinSignedMessage:
+	default:
+		return nil, ErrUnsupportedAIPAlgorithm
+	}
+	fieldCount := aipFieldCount(b.Protocols)
+	for _, idx := range fieldIndexes {
+		if idx < 0 || idx >= fieldCount {
+			return nil, fmt.Errorf("%w: %d of %d fields", ErrInvalidFieldIndex, idx, fieldCount)
+		}
+	}
+	if len(fieldIndexes) == 0 {
+		fieldIndexes = nil
+	}
 
-			validateAip(aip, b.Protocols[:protoIdx])
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := bsm.SignMessage(key, aipMessage(b.Protocols, fieldIndexes))
+	if err != nil {
+		return nil, err
+	}
+	return &AIP{
+		BitcomIndex:  uint(len(b.Protocols)),
+		Algorithm:    algorithm,
+		Address:      address.AddressString,
+		Signature:    sig,
+		FieldIndexes: fieldIndexes,
+		Valid:        true,
+		Status:       AIPVerified,
+	}, nil
+}
 
-			aips = append(aips, aip)
+// SignAIPPaymail signs the protocols of b with key and returns the unappended AIP
+// using the paymail algorithm, with the paymail handle as its address. Verifiers
+// must be able to resolve the handle to key's public key.
+func SignAIPPaymail(b *Bitcom, key *ec.PrivateKey, paymail string, fieldIndexes ...int) (*AIP, error) {
+	aip, err := SignAIP(b, key, AIPAlgoBitcoinECDSA, fieldIndexes...)
+	if err != nil {
+		return nil, err
+	}
+	aip.Algorithm = AIPAlgoPaymail
+	aip.Address = paymail
+	aip.PubKey = hex.EncodeToString(key.PubKey().Compressed())
+	return aip, nil
+}
+
+// SignAIP signs the protocols of b with key and appends the AIP protocol
+func (b *Bitcom) SignAIP(key *ec.PrivateKey, algorithm string, fieldIndexes ...int) (*AIP, error) {
+	aip, err := SignAIP(b, key, algorithm, fieldIndexes...)
+	if err != nil {
+		return nil, err
+	}
+	p, err := aip.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b.Protocols = append(b.Protocols, p)
+	return aip, nil
+}
+
+// Encode encodes the AIP as a Bitcom protocol segment:
+// ALGORITHM ADDRESS SIGNATURE [FIELD_INDEX ...]
+func (aip *AIP) Encode() (*BitcomProtocol, error) {
+	fields := [][]byte{
+		[]byte(aip.Algorithm),
+		[]byte(aip.Address),
+		aip.Signature,
+	}
+	for _, idx := range aip.FieldIndexes {
+		fields = append(fields, []byte(strconv.Itoa(idx)))
+	}
+	return newProtocol(AIPPrefix, fields...)
+}
+
+// VerifyAIP verifies the AIP found at aip.BitcomIndex of b, updating Valid and PubKey,
+// and returns the public key that made the signature.
+//
+// Bitcoin address algorithms are verified against the address. With the paymail
+// algorithm the address is resolved to public keys with resolver, and the signature
+// must be made by one of them. Both compact (BSM) signatures and DER signatures over
+// the sha256 of the message are accepted for paymail.
+func VerifyAIP(ctx context.Context, b *Bitcom, aip *AIP, resolver PubKeyResolver) (*ec.PublicKey, error) {
+	pubKey, err := verifyAIP(ctx, b, aip, resolver)
+	switch {
+	case err == nil:
+		aip.Status = AIPVerified
+		aip.PubKey = hex.EncodeToString(pubKey.Compressed())
+	case errors.Is(err, ErrAIPSignatureMismatch):
+		aip.Status, aip.PubKey = AIPFailed, ""
+	default:
+		aip.Status, aip.PubKey = AIPUnverified, ""
+	}
+	aip.Valid = aip.Status == AIPVerified
+	return pubKey, err
+}
+
+// verifyAIP checks the signature of aip, returning ErrAIPSignatureMismatch if it
+// was checked and is not valid
+func verifyAIP(ctx context.Context, b *Bitcom, aip *AIP, resolver PubKeyResolver) (*ec.PublicKey, error) {
+	if b == nil || int(aip.BitcomIndex) > len(b.Protocols) {
+		return nil, ErrNoProtocols
+	}
+	data := aipMessage(b.Protocols[:aip.BitcomIndex], aip.FieldIndexes)
+
+	var pubKey *ec.PublicKey
+	switch aip.Algorithm {
+	case AIPAlgoBitcoinECDSA, AIPAlgoBitcoinSignedMessage:
+		if err := bsm.VerifyMessage(aip.Address, aip.Signature, data); err != nil {
+			return nil, errors.Join(ErrAIPSignatureMismatch, err)
 		}
+		var err error
+		if pubKey, _, err = bsm.PubKeyFromSignature(aip.Signature, data); err != nil {
+			return nil, err
+		}
+	case AIPAlgoPaymail:
+		if resolver == nil {
+			return nil, ErrNoPubKeyResolver
+		}
+		keys, err := resolver.ResolvePubKeys(ctx, aip.Address)
+		if err != nil {
+			return nil, err
+		}
+		if pubKey = matchPubKey(aip.Signature, data, keys); pubKey == nil {
+			return nil, ErrAIPSignatureMismatch
+		}
+	default:
+		return nil, ErrUnsupportedAIPAlgorithm
 	}
+	return pubKey, nil
+}
 
-	return aips
+// matchPubKey returns the key in keys that made sig over data, or nil if none did
+func matchPubKey(sig, data []byte, keys []*ec.PublicKey) *ec.PublicKey {
+	if recovered, _, err := bsm.PubKeyFromSignature(sig, data); err == nil {
+		for _, key := range keys {
+			if key.IsEqual(recovered) {
+				return key
+			}
+		}
+		return nil
+	}
+	der, err := ec.ParseDERSignature(sig)
+	if err != nil {
+		return nil
+	}
+	digest := sha256.Sum256(data)
+	for _, key := range keys {
+		if der.Verify(digest[:], key) {
+			return key
+		}
+	}
+	return nil
 }
 
+// validateAip checks the signature of a decoded AIP against its address. A paymail
+// address must be resolved to public keys first, so it is left unverified for
+// VerifyAIP rather than reported as failed.
 func validateAip(aip *AIP, protos []*BitcomProtocol) {
+	if aip.Algorithm == AIPAlgoPaymail {
+		aip.Status = AIPUnverified
+		return
+	}
+	data := aipMessage(protos, aip.FieldIndexes)
+	// if sig, err := base64.StdEncoding.DecodeString(aip.Signature); err != nil {
+	// 	return
+	// } else if err := bsm.VerifyMessage(aip.Address, sig, data); err == nil {
+	if err := bsm.VerifyMessage(aip.Address, aip.Signature, data); err == nil {
+		aip.Valid = true
+		aip.Status = AIPVerified
+	} else {
+		aip.Status = AIPFailed
+	}
+}
+
+// aipFieldCount returns the number of fields in protos that field indexes refer to
+func aipFieldCount(protos []*BitcomProtocol) int {
+	count := 0
+	for _, p := range protos {
+		if tape, err := script.DecodeScript(p.Script); err == nil {
+			count += len(tape)
+		}
+	}
+	return count
+}
+
+// aipMessage builds the message signed by an AIP from the protocols preceding it.
+// If fieldIndexes is nil every field is included.
+func aipMessage(protos []*BitcomProtocol, fieldIndexes []int) []byte {
 	data := make([]byte, 0)
 	idx := 0
 	data = append(data, script.OpRETURN)
@@ -99,7 +339,7 @@ func validateAip(aip *AIP, protos []*BitcomProtocol) {
 			continue
 		} else {
 			for _, op := range tape {
-				if (op.Op > 0 || op.Op <= 0x4e) && (aip.FieldIndexes == nil || slices.Contains(aip.FieldIndexes, idx)) {
+				if (op.Op > 0 || op.Op <= 0x4e) && (fieldIndexes == nil || slices.Contains(fieldIndexes, idx)) {
 					data = append(data, string(op.Data)...)
 				} else if op.Op > 0x43 && unicode.IsPrint(rune(op.Op)) {
 					data = append(data, op.Op)
@@ -109,10 +349,5 @@ func validateAip(aip *AIP, protos []*BitcomProtocol) {
 		}
 		data = append(data, '|')
 	}
-	// if sig, err := base64.StdEncoding.DecodeString(aip.Signature); err != nil {
-	// 	return
-	// } else if err := bsm.VerifyMessage(aip.Address, sig, data); err == nil {
-	if err := bsm.VerifyMessage(aip.Address, aip.Signature, data); err == nil {
-		aip.Valid = true
-	}
+	return data
 }
@@ -1,11 +1,15 @@
 package bitcom
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/stretchr/testify/require"
@@ -451,3 +455,284 @@ func TestDecodeAIPWithFieldIndexes(t *testing.T) {
 	require.Equal(t, 1, aips[0].FieldIndexes[1], "Second field index should be 1")
 	require.Equal(t, 2, aips[0].FieldIndexes[2], "Third field index should be 2")
 }
+
+// TestSignAIP verifies that AIP signatures created in bitcom are verified by DecodeAIP
+func TestSignAIP(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		algorithm    string
+		fieldIndexes []int
+	}{
+		{"all fields", AIPAlgoBitcoinECDSA, nil},
+		{"BitcoinSignedMessage label", AIPAlgoBitcoinSignedMessage, nil},
+		{"default algorithm", "", nil},
+		{"field indexes", AIPAlgoBitcoinECDSA, []int{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bitcom{ScriptPrefix: []byte{script.OpFALSE}}
+			b.Protocols = append(b.Protocols,
+				mustProtocol(t, BPrefix, "Hello world", string(MediaTypeTextPlain), string(EncodingUTF8)),
+				mustProtocol(t, MapPrefix, "SET", "app", "test"),
+			)
+
+			signed, err := b.SignAIP(key, tt.algorithm, tt.fieldIndexes...)
+			require.NoError(t, err)
+			require.Len(t, b.Protocols, 3, "AIP should be appended")
+			require.Equal(t, AIPPrefix, b.Protocols[2].Protocol)
+			require.Equal(t, address.AddressString, signed.Address)
+
+			aips := DecodeAIP(Decode(b.Lock()))
+			require.Len(t, aips, 1)
+			require.True(t, aips[0].Valid, "Signature should verify")
+			require.Equal(t, signed.Algorithm, aips[0].Algorithm)
+			require.Equal(t, tt.fieldIndexes, aips[0].FieldIndexes)
+			require.Equal(t, uint(2), aips[0].BitcomIndex)
+		})
+	}
+}
+
+// TestSignAIP_FieldIndexes verifies that only the indexed fields are covered by the signature
+func TestSignAIP_FieldIndexes(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	build := func(appName string) *Bitcom {
+		return &Bitcom{
+			ScriptPrefix: []byte{script.OpFALSE},
+			Protocols: []*BitcomProtocol{
+				mustProtocol(t, BPrefix, "Hello world", string(MediaTypeTextPlain), string(EncodingUTF8)),
+				mustProtocol(t, MapPrefix, "SET", "app", appName),
+			},
+		}
+	}
+
+	// Sign only the B fields
+	signed, err := SignAIP(build("test"), key, AIPAlgoBitcoinECDSA, 0, 1, 2)
+	require.NoError(t, err)
+	p, err := signed.Encode()
+	require.NoError(t, err)
+
+	// Changing an unsigned MAP field keeps the signature valid
+	b := build("changed")
+	b.Protocols = append(b.Protocols, p)
+	aips := DecodeAIP(Decode(b.Lock()))
+	require.Len(t, aips, 1)
+	require.True(t, aips[0].Valid, "Unsigned fields may change")
+
+	// A signature over every field is invalidated by the same change
+	all, err := SignAIP(build("test"), key, AIPAlgoBitcoinECDSA)
+	require.NoError(t, err)
+	p, err = all.Encode()
+	require.NoError(t, err)
+	b = build("changed")
+	b.Protocols = append(b.Protocols, p)
+	aips = DecodeAIP(Decode(b.Lock()))
+	require.Len(t, aips, 1)
+	require.False(t, aips[0].Valid, "Signed fields may not change")
+}
+
+// TestSignAIP_Errors verifies invalid signing requests are rejected
+func TestSignAIP_Errors(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	b := &Bitcom{Protocols: []*BitcomProtocol{mustProtocol(t, MapPrefix, "SET", "app", "test")}}
+
+	_, err = SignAIP(b, nil, AIPAlgoBitcoinECDSA)
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+
+	_, err = SignAIP(&Bitcom{}, key, AIPAlgoBitcoinECDSA)
+	require.ErrorIs(t, err, ErrNoProtocols)
+
+	_, err = SignAIP(b, key, "SHA256")
+	require.ErrorIs(t, err, ErrUnsupportedAIPAlgorithm)
+
+	_, err = SignAIP(b, key, AIPAlgoBitcoinECDSA, -1)
+	require.ErrorIs(t, err, ErrInvalidFieldIndex)
+
+	// The MAP protocol has three fields, so index 3 is past the last one
+	_, err = SignAIP(b, key, AIPAlgoBitcoinECDSA, 0, 3)
+	require.ErrorIs(t, err, ErrInvalidFieldIndex)
+	_, err = SignAIP(b, key, AIPAlgoBitcoinECDSA, 2)
+	require.NoError(t, err, "The last field may be signed")
+
+	_, err = b.SignAIP(nil, AIPAlgoBitcoinECDSA)
+	require.Error(t, err)
+	require.Len(t, b.Protocols, 1, "Nothing should be appended on error")
+}
+
+// mustProtocol creates a protocol segment with one push per field
+func mustProtocol(t *testing.T, prefix string, fields ...string) *BitcomProtocol {
+	t.Helper()
+	data := make([][]byte, 0, len(fields))
+	for _, f := range fields {
+		data = append(data, []byte(f))
+	}
+	p, err := newProtocol(prefix, data...)
+	require.NoError(t, err)
+	return p
+}
+
+// TestVerifyAIP_Paymail verifies paymail AIP signatures through a public key resolver
+func TestVerifyAIP_Paymail(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	const paymail = "satoshi@example.com"
+	oldKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	resolver := NewStaticPubKeyResolver()
+	resolver.Add("Satoshi@Example.com", oldKey.PubKey(), key.PubKey())
+
+	b := &Bitcom{
+		ScriptPrefix: []byte{script.OpFALSE},
+		Protocols:    []*BitcomProtocol{mustProtocol(t, MapPrefix, "SET", "app", "test")},
+	}
+	signed, err := SignAIPPaymail(b, key, paymail)
+	require.NoError(t, err)
+	p, err := signed.Encode()
+	require.NoError(t, err)
+	b.Protocols = append(b.Protocols, p)
+
+	// Decoding alone cannot verify a paymail signature
+	decoded := Decode(b.Lock())
+	aips := DecodeAIP(decoded)
+	require.Len(t, aips, 1)
+	require.Equal(t, AIPAlgoPaymail, aips[0].Algorithm)
+	require.Equal(t, paymail, aips[0].Address)
+	require.False(t, aips[0].Valid, "Paymail AIP cannot be verified without a resolver")
+	require.Equal(t, AIPUnverified, aips[0].Status)
+
+	matched, err := VerifyAIP(context.Background(), decoded, aips[0], resolver)
+	require.NoError(t, err)
+	require.True(t, matched.IsEqual(key.PubKey()), "Should report the key that signed")
+	require.True(t, aips[0].Valid)
+	require.Equal(t, AIPVerified, aips[0].Status)
+	require.Equal(t, signed.PubKey, aips[0].PubKey)
+
+	// Errors
+	_, err = VerifyAIP(context.Background(), decoded, aips[0], nil)
+	require.ErrorIs(t, err, ErrNoPubKeyResolver)
+	require.False(t, aips[0].Valid)
+	require.Equal(t, AIPUnverified, aips[0].Status)
+
+	_, err = VerifyAIP(context.Background(), decoded, aips[0], NewStaticPubKeyResolver())
+	require.ErrorIs(t, err, ErrPubKeysNotFound)
+
+	other := NewStaticPubKeyResolver()
+	other.Add(paymail, oldKey.PubKey())
+	_, err = VerifyAIP(context.Background(), decoded, aips[0], other)
+	require.ErrorIs(t, err, ErrAIPSignatureMismatch)
+	require.Equal(t, AIPFailed, aips[0].Status)
+}
+
+// TestDecodeAIP_PaymailScript verifies that a decoded paymail signature is
+// unverified, while a forged address signature fails
+func TestDecodeAIP_PaymailScript(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	b := &Bitcom{
+		ScriptPrefix: []byte{script.OpFALSE},
+		Protocols:    []*BitcomProtocol{mustProtocol(t, MapPrefix, "SET", "app", "test")},
+	}
+	paymail, err := SignAIPPaymail(b, key, "alice@example.com")
+	require.NoError(t, err)
+	forged, err := SignAIP(b, key, AIPAlgoBitcoinECDSA)
+	require.NoError(t, err)
+	forged.Address = "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz"
+
+	tests := []struct {
+		name   string
+		aip    *AIP
+		status AIPStatus
+	}{
+		{"paymail", paymail, AIPUnverified},
+		{"forged address", forged, AIPFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.aip.Encode()
+			require.NoError(t, err)
+			signed := &Bitcom{ScriptPrefix: b.ScriptPrefix, Protocols: append(slices.Clone(b.Protocols), p)}
+
+			aips := DecodeAIP(Decode(signed.Lock()))
+			require.Len(t, aips, 1)
+			require.Equal(t, tt.status, aips[0].Status)
+			require.False(t, aips[0].Valid)
+		})
+	}
+}
+
+// TestVerifyAIP_PaymailDER verifies DER signatures over the sha256 of the message
+func TestVerifyAIP_PaymailDER(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	resolver := NewStaticPubKeyResolver()
+	resolver.Add("alice@example.com", key.PubKey())
+
+	b := &Bitcom{Protocols: []*BitcomProtocol{mustProtocol(t, MapPrefix, "SET", "app", "test")}}
+	digest := sha256.Sum256(aipMessage(b.Protocols, nil))
+	sig, err := key.Sign(digest[:])
+	require.NoError(t, err)
+
+	aip := &AIP{
+		BitcomIndex: 1,
+		Algorithm:   AIPAlgoPaymail,
+		Address:     "alice@example.com",
+		Signature:   sig.Serialize(),
+	}
+	matched, err := VerifyAIP(context.Background(), b, aip, resolver)
+	require.NoError(t, err)
+	require.True(t, matched.IsEqual(key.PubKey()))
+	require.True(t, aip.Valid)
+}
+
+// TestVerifyAIP_Address verifies Bitcoin address AIPs report the signing key
+func TestVerifyAIP_Address(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	b := &Bitcom{Protocols: []*BitcomProtocol{mustProtocol(t, MapPrefix, "SET", "app", "test")}}
+	aip, err := b.SignAIP(key, AIPAlgoBitcoinSignedMessage)
+	require.NoError(t, err)
+
+	matched, err := VerifyAIP(context.Background(), b, aip, nil)
+	require.NoError(t, err, "Address AIPs do not need a resolver")
+	require.True(t, matched.IsEqual(key.PubKey()))
+
+	aip.Address = "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz"
+	_, err = VerifyAIP(context.Background(), b, aip, nil)
+	require.ErrorIs(t, err, ErrAIPSignatureMismatch)
+	require.False(t, aip.Valid)
+
+	aip.Algorithm = "SHA256"
+	_, err = VerifyAIP(context.Background(), b, aip, nil)
+	require.ErrorIs(t, err, ErrUnsupportedAIPAlgorithm)
+}
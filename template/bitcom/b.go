@@ -1,6 +1,14 @@
 package bitcom
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/bsv-blockchain/go-sdk/script"
 )
 
@@ -23,10 +31,24 @@ const (
 type Encoding string
 
 var (
-	EncodingUTF8  Encoding = "utf-8"
-	EncodingBinay Encoding = "binary"
+	EncodingUTF8   Encoding = "utf-8"
+	EncodingBinary Encoding = "binary"
+	EncodingGzip   Encoding = "gzip"
+
+	// Deprecated: use EncodingBinary
+	EncodingBinay = EncodingBinary
+)
+
+var (
+	ErrInvalidMediaType    = errors.New("invalid B media type")
+	ErrUnsupportedEncoding = errors.New("unsupported B encoding")
+	ErrInvalidUTF8         = errors.New("B data is not valid utf-8")
+	ErrInvalidGzip         = errors.New("B data is not gzip compressed")
 )
 
+// gzipMagic is the header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // B represents B protocol data
 type B struct {
 	MediaType MediaType `json:"mediaType"`
@@ -40,7 +62,17 @@ type B struct {
 // DATA MEDIA_TYPE ENCODING [FILENAME]
 // Where FILENAME is optional. Returns nil if the script is invalid or cannot be parsed.
 func DecodeB(data any) *B {
-	scr := ToScript(data)
+	return decodeB(ToScript(data), ignoreWarning)
+}
+
+// decodeBAt decodes the B protocol at index idx of the Bitcom, recording why it
+// could not be decoded as a warning
+func decodeBAt(b *Bitcom, idx int) *B {
+	return decodeB(ToScript(b.Protocols[idx].Script), b.warnAt(idx))
+}
+
+// decodeB decodes B data from scr, reporting problems to warn
+func decodeB(scr *script.Script, warn warnFunc) *B {
 	if scr == nil {
 		return nil
 	}
@@ -55,19 +87,19 @@ func DecodeB(data any) *B {
 	// Skip prefix as it's already checked
 
 	// Read DATA
-	if op, err = scr.ReadOp(&pos); err != nil {
+	if op, err = readField(scr, &pos, "B data", warn); err != nil {
 		return nil
 	}
 	b.Data = op.Data
 
 	// Read MEDIA_TYPE
-	if op, err = scr.ReadOp(&pos); err != nil {
+	if op, err = readField(scr, &pos, "B media type", warn); err != nil {
 		return nil
 	}
 	b.MediaType = MediaType(op.Data)
 
 	// Read ENCODING
-	if op, err = scr.ReadOp(&pos); err != nil {
+	if op, err = readField(scr, &pos, "B encoding", warn); err != nil {
 		return nil
 	}
 	b.Encoding = Encoding(op.Data)
@@ -80,3 +112,80 @@ func DecodeB(data any) *B {
 
 	return b
 }
+
+// Validate checks that the media type is a well-formed type/subtype with optional
+// parameters, that the encoding is supported, and that the data matches the encoding.
+// Encodings are case-insensitive and the encoding is pushed exactly as given.
+func (b *B) Validate() error {
+	if _, _, err := mime.ParseMediaType(string(b.MediaType)); err != nil {
+		return errors.Join(ErrInvalidMediaType, err)
+	}
+	switch b.normalizedEncoding() {
+	case EncodingUTF8:
+		if !utf8.Valid(b.Data) {
+			return ErrInvalidUTF8
+		}
+	case EncodingGzip:
+		if !bytes.HasPrefix(b.Data, gzipMagic) {
+			return ErrInvalidGzip
+		}
+	case EncodingBinary:
+	default:
+		return ErrUnsupportedEncoding
+	}
+	return nil
+}
+
+// normalizedEncoding returns the lower case encoding, mapping the legacy "text"
+// encoding used by some applications (e.g. Twetch) to utf-8
+func (b *B) normalizedEncoding() Encoding {
+	enc := Encoding(strings.ToLower(string(b.Encoding)))
+	if enc == "text" {
+		return EncodingUTF8
+	}
+	return enc
+}
+
+// Encode validates the B data and encodes it as a Bitcom protocol segment:
+// DATA MEDIA_TYPE ENCODING [FILENAME]
+func (b *B) Encode() (*BitcomProtocol, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	fields := [][]byte{b.Data, []byte(b.MediaType), []byte(b.Encoding)}
+	if b.Filename != "" {
+		fields = append(fields, []byte(b.Filename))
+	}
+	return newProtocol(BPrefix, fields...)
+}
+
+// Content returns the data with any gzip encoding removed
+func (b *B) Content() ([]byte, error) {
+	if b.normalizedEncoding() != EncodingGzip {
+		return b.Data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b.Data))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidGzip, err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// NewGzipB compresses data and returns a B with the gzip encoding
+func NewGzipB(data []byte, mediaType MediaType, filename string) (*B, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &B{
+		MediaType: mediaType,
+		Encoding:  EncodingGzip,
+		Data:      buf.Bytes(),
+		Filename:  filename,
+	}, nil
+}
@@ -3,6 +3,7 @@ package bitcom
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -184,3 +185,113 @@ func TestDecodeB_Bytes(t *testing.T) {
 	result = DecodeB(invalidBytes)
 	require.Nil(t, result, "Expected nil result for invalid script bytes")
 }
+
+// TestBEncode_RoundTrip verifies that every B protocol in the testdata vectors
+// re-encodes to exactly the bytes it was decoded from
+func TestBEncode_RoundTrip(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	files, err := filepath.Glob("testdata/*.hex")
+	require.NoError(t, err)
+	bsocialFiles, err := filepath.Glob("../bsocial/testdata/*.hex")
+	require.NoError(t, err)
+	files = append(files, bsocialFiles...)
+
+	found := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err, "Failed to read %s", file)
+		tx, err := transaction.NewTransactionFromHex(strings.TrimSpace(string(data)))
+		require.NoError(t, err, "Failed to parse %s", file)
+
+		for vout, output := range tx.Outputs {
+			bc := Decode(output.LockingScript)
+			if bc == nil {
+				continue
+			}
+			for _, proto := range bc.Protocols {
+				if proto.Protocol != BPrefix {
+					continue
+				}
+				found++
+
+				b := DecodeB(proto.Script)
+				require.NotNil(t, b, "%s:%d should decode", file, vout)
+
+				encoded, err := b.Encode()
+				require.NoError(t, err, "%s:%d should encode", file, vout)
+				require.Equal(t, BPrefix, encoded.Protocol)
+				require.Equal(t, proto.Script, encoded.Script, "%s:%d should round trip", file, vout)
+				t.Logf("%s:%d round tripped %s (%d bytes)", filepath.Base(file), vout, b.MediaType, len(b.Data))
+			}
+		}
+	}
+	require.NotZero(t, found, "Expected B protocols in the testdata")
+}
+
+// TestBEncode_Validation verifies media type, encoding and data validation
+func TestBEncode_Validation(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	gz, err := NewGzipB([]byte("Hello world"), MediaTypeTextPlain, "hello.txt")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		b        *B
+		expected error
+	}{
+		{"utf-8", &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: EncodingUTF8}, nil},
+		{"binary", &B{Data: []byte{0xff, 0xd8}, MediaType: MediaTypeImageJPEG, Encoding: EncodingBinary}, nil},
+		{"gzip", gz, nil},
+		{"upper case encoding", &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: "UTF-8"}, nil},
+		{"legacy text encoding", &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: "text"}, nil},
+		{"media type parameters", &B{Data: []byte("Hello"), MediaType: "text/plain; charset=utf-8", Encoding: EncodingUTF8}, nil},
+		{"empty media type", &B{Data: []byte("Hello"), Encoding: EncodingUTF8}, ErrInvalidMediaType},
+		{"missing subtype", &B{Data: []byte("Hello"), MediaType: "text/", Encoding: EncodingUTF8}, ErrInvalidMediaType},
+		{"unsupported encoding", &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: "base64"}, ErrUnsupportedEncoding},
+		{"invalid utf-8", &B{Data: []byte{0xff, 0xfe}, MediaType: MediaTypeTextPlain, Encoding: EncodingUTF8}, ErrInvalidUTF8},
+		{"invalid gzip", &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: EncodingGzip}, ErrInvalidGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.b.Encode()
+			if tt.expected == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBEncode_Builder verifies that B can be composed with other protocols and decoded back
+func TestBEncode_Builder(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	gz, err := NewGzipB([]byte("# Hello world"), MediaTypeTextMarkdown, "hello.md")
+	require.NoError(t, err)
+
+	m := &Map{Cmd: MapCmdSet}
+	m.Set("app", "test")
+
+	s, err := NewBuilder().Add(gz).Add(m).Build()
+	require.NoError(t, err)
+
+	b, ok := FirstValue[*B](DecodeAll(s))
+	require.True(t, ok, "Should decode B")
+	require.Equal(t, gz, b)
+
+	content, err := b.Content()
+	require.NoError(t, err)
+	require.Equal(t, "# Hello world", string(content))
+
+	plain := &B{Data: []byte("Hello"), MediaType: MediaTypeTextPlain, Encoding: EncodingUTF8}
+	content, err = plain.Content()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", string(content))
+}
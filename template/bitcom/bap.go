@@ -1,10 +1,15 @@
 package bitcom
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"strconv"
 	"strings"
 
+	base58 "github.com/bsv-blockchain/go-sdk/compat/base58"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
 	"github.com/bsv-blockchain/go-sdk/script"
 )
 
@@ -23,6 +28,13 @@ const (
 	ALIAS  AttestationType = "ALIAS"
 )
 
+var (
+	ErrUnknownBAPType    = errors.New("unknown BAP type")
+	ErrMissingBAPIDKey   = errors.New("BAP record has no identity key or attestation hash")
+	ErrMissingBAPAddress = errors.New("BAP ID has no address")
+	ErrInvalidBAPProfile = errors.New("BAP alias profile is not valid JSON")
+)
+
 // Bap represents a Bitcoin Attestation Protocol data structure
 type Bap struct {
 	BitcomIndex  uint            `json:"ii,omitempty"` // Index of the AIP in the Bitcom transaction
@@ -32,9 +44,10 @@ type Bap struct {
 	Sequence     uint64          `json:"sequence"`
 	Algorithm    string          `json:"algorithm,omitempty"`    // AIP algorithm
 	SignerAddr   string          `json:"signer_addr,omitempty"`  // AIP signing address
-	Signature    string          `json:"signature,omitempty"`    // AIP signature
+	Signature    string          `json:"signature,omitempty"`    // AIP signature, base64 encoded
 	RootAddress  string          `json:"root_address,omitempty"` // For ID
-	IsSignedByID bool            `json:"is_signed_by_id"`        // Whether it's signed by the ID
+	IsSignedByID bool            `json:"is_signed_by_id"`        // Whether it's signed by the ID, see BAPState.Accepted
+	Valid        bool            `json:"valid,omitempty"`        // Whether the AIP signature is valid
 	Profile      json.RawMessage `json:"profile,omitempty"`      // Profile for ID
 }
 
@@ -49,191 +62,367 @@ func DecodeBAP(b *Bitcom) *Bap {
 	for ii, proto := range b.Protocols {
 		// Check if this is a BAP protocol entry
 		if proto.Protocol == BAPPrefix {
-			// Create a BAP struct to hold the decoded data
-			bap := &Bap{
-				BitcomIndex: uint(ii),
+			if bap := decodeBAPAt(b, ii); bap != nil {
+				return bap
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeBAPAt decodes the BAP protocol at index ii of the Bitcom.
+// Returns nil if the protocol is not BAP or cannot be parsed.
+func decodeBAPAt(b *Bitcom, ii int) *Bap {
+	proto := b.Protocols[ii]
+	if proto.Protocol != BAPPrefix {
+		return nil
+	}
+
+	// Create a BAP struct to hold the decoded data
+	bap := &Bap{
+		BitcomIndex: uint(ii),
+	}
+
+	// Parse script into chunks for analysis
+	scr := script.NewFromBytes(proto.Script)
+	if scr == nil {
+		return nil
+	}
+
+	/*
+		I fixed this in bitcom.Lock(). It was constructing the script improperly and pushing it as one big pushdata
+	*/
+
+	// // Try a direct approach to extract the data
+	// s := proto.Script
+	// var pos int
+
+	// // Skip the first byte if it's a length byte (like 0x3c which is 60 in decimal)
+	// if len(s) > 0 && s[0] > 0 && s[0] < 0x4c {
+	// 	pos = 1
+	// }
+
+	// // Create a temp slice for the script data without the length byte
+	// scriptData := s[pos:]
+	// tempScr := script.NewFromBytes(scriptData)
+	// if tempScr == nil {
+	// 	continue
+	// }
+
+	// Now try to get the chunks
+	chunks, err := scr.Chunks()
+	if err != nil || len(chunks) < 2 { // Need at least TYPE and one other field
+		// If parsing as chunks failed, try a different approach
+		// Check if we can find the ID or ATTEST type in the script
+		scriptStr := string(*scr)
+
+		if strings.Contains(scriptStr, string(ID)) {
+			// Found ID type
+			parts := strings.SplitN(scriptStr, string(ID), 2)
+			if len(parts) > 1 {
+				bap.Type = ID
+				remainingParts := strings.SplitN(parts[1], " ", 3)
+				if len(remainingParts) >= 2 {
+					bap.IDKey = strings.TrimSpace(remainingParts[0])
+					bap.Address = strings.TrimSpace(remainingParts[1])
+					return bap
+				}
+			}
+		} else if strings.Contains(scriptStr, string(ATTEST)) {
+			// Found ATTEST type
+			parts := strings.SplitN(scriptStr, string(ATTEST), 2)
+			if len(parts) > 1 {
+				bap.Type = ATTEST
+				remainingParts := strings.SplitN(parts[1], " ", 3)
+				if len(remainingParts) >= 2 {
+					bap.IDKey = strings.TrimSpace(remainingParts[0])
+					bap.Sequence, _ = strconv.ParseUint(remainingParts[1], 10, 64)
+					return bap
+				}
 			}
+		}
 
-			// Parse script into chunks for analysis
-			scr := script.NewFromBytes(proto.Script)
-			if scr == nil {
-				continue
+		b.AddWarning(ii, 0, "BAP requires a type and at least one field")
+		return nil
+	}
+
+	// Parse BAP data fields
+	// First chunk should be the TYPE (ATTEST, ID, REVOKE, ALIAS)
+	bap.Type = AttestationType(chunks[0].Data)
+
+	// Process based on the BAP type
+	switch bap.Type {
+	case ID:
+		// ID structure: ID <identity key> <address>
+		if len(chunks) >= 3 {
+			bap.IDKey = string(chunks[1].Data)
+			bap.Address = string(chunks[2].Data)
+
+			// Look for AIP signature data which follows a pipe separator
+			pipeIdx := -1
+			for i := 3; i < len(chunks); i++ {
+				if string(chunks[i].Data) == pipeSeparator {
+					pipeIdx = i
+					break
+				}
 			}
 
-			/*
-				I fixed this in bitcom.Lock(). It was constructing the script improperly and pushing it as one big pushdata
-			*/
-
-			// // Try a direct approach to extract the data
-			// s := proto.Script
-			// var pos int
-
-			// // Skip the first byte if it's a length byte (like 0x3c which is 60 in decimal)
-			// if len(s) > 0 && s[0] > 0 && s[0] < 0x4c {
-			// 	pos = 1
-			// }
-
-			// // Create a temp slice for the script data without the length byte
-			// scriptData := s[pos:]
-			// tempScr := script.NewFromBytes(scriptData)
-			// if tempScr == nil {
-			// 	continue
-			// }
-
-			// Now try to get the chunks
-			chunks, err := scr.Chunks()
-			if err != nil || len(chunks) < 2 { // Need at least TYPE and one other field
-				// If parsing as chunks failed, try a different approach
-				// Check if we can find the ID or ATTEST type in the script
-				scriptStr := string(*scr)
-
-				if strings.Contains(scriptStr, string(ID)) {
-					// Found ID type
-					parts := strings.SplitN(scriptStr, string(ID), 2)
-					if len(parts) > 1 {
-						bap.Type = ID
-						remainingParts := strings.SplitN(parts[1], " ", 3)
-						if len(remainingParts) >= 2 {
-							bap.IDKey = strings.TrimSpace(remainingParts[0])
-							bap.Address = strings.TrimSpace(remainingParts[1])
-							return bap
-						}
-					}
-				} else if strings.Contains(scriptStr, string(ATTEST)) {
-					// Found ATTEST type
-					parts := strings.SplitN(scriptStr, string(ATTEST), 2)
-					if len(parts) > 1 {
-						bap.Type = ATTEST
-						remainingParts := strings.SplitN(parts[1], " ", 3)
-						if len(remainingParts) >= 2 {
-							bap.IDKey = strings.TrimSpace(remainingParts[0])
-							bap.Sequence, _ = strconv.ParseUint(remainingParts[1], 10, 64)
-							return bap
-						}
-					}
+			if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
+				// AIP signature data found
+				bap.Algorithm = string(chunks[pipeIdx+2].Data)
+				bap.SignerAddr = string(chunks[pipeIdx+3].Data)
+				if pipeIdx+4 < len(chunks) {
+					bap.Signature = string(chunks[pipeIdx+4].Data)
+					bap.RootAddress = bap.SignerAddr // In ID, the signer is the root address
+					bap.IsSignedByID = true
 				}
+			}
+		}
+
+	case ATTEST:
+		// ATTEST structure: ATTEST <txid> <sequence number>
+		if len(chunks) >= 3 {
+			bap.IDKey = string(chunks[1].Data) // TXID being attested to
+			bap.Sequence, _ = strconv.ParseUint(string(chunks[2].Data), 10, 64)
 
-				continue
+			// Look for AIP signature data
+			pipeIdx := -1
+			for i := 3; i < len(chunks); i++ {
+				if string(chunks[i].Data) == pipeSeparator {
+					pipeIdx = i
+					break
+				}
 			}
 
-			// Parse BAP data fields
-			// First chunk should be the TYPE (ATTEST, ID, REVOKE, ALIAS)
-			bap.Type = AttestationType(chunks[0].Data)
-
-			// Process based on the BAP type
-			switch bap.Type {
-			case ID:
-				// ID structure: ID <identity key> <address>
-				if len(chunks) >= 3 {
-					bap.IDKey = string(chunks[1].Data)
-					bap.Address = string(chunks[2].Data)
-
-					// Look for AIP signature data which follows a pipe separator
-					pipeIdx := -1
-					for i := 3; i < len(chunks); i++ {
-						if string(chunks[i].Data) == pipeSeparator {
-							pipeIdx = i
-							break
-						}
-					}
-
-					if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
-						// AIP signature data found
-						bap.Algorithm = string(chunks[pipeIdx+2].Data)
-						bap.SignerAddr = string(chunks[pipeIdx+3].Data)
-						if pipeIdx+4 < len(chunks) {
-							bap.Signature = string(chunks[pipeIdx+4].Data)
-							bap.RootAddress = bap.SignerAddr // In ID, the signer is the root address
-							bap.IsSignedByID = true
-						}
-					}
+			if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
+				// AIP signature data found
+				bap.Algorithm = string(chunks[pipeIdx+2].Data)
+				bap.SignerAddr = string(chunks[pipeIdx+3].Data)
+				if pipeIdx+4 < len(chunks) {
+					bap.Signature = string(chunks[pipeIdx+4].Data)
+					// Whether the signer is the identity's current address is tracked by BAPState.Accepted
+					bap.IsSignedByID = false
 				}
+			}
+		}
+
+	case REVOKE:
+		// REVOKE structure: REVOKE <txid> <sequence number>
+		if len(chunks) >= 3 {
+			bap.IDKey = string(chunks[1].Data) // TXID being revoked
+			bap.Sequence, _ = strconv.ParseUint(string(chunks[2].Data), 10, 64)
 
-			case ATTEST:
-				// ATTEST structure: ATTEST <txid> <sequence number>
-				if len(chunks) >= 3 {
-					bap.IDKey = string(chunks[1].Data) // TXID being attested to
-					bap.Sequence, _ = strconv.ParseUint(string(chunks[2].Data), 10, 64)
-
-					// Look for AIP signature data
-					pipeIdx := -1
-					for i := 3; i < len(chunks); i++ {
-						if string(chunks[i].Data) == pipeSeparator {
-							pipeIdx = i
-							break
-						}
-					}
-
-					if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
-						// AIP signature data found
-						bap.Algorithm = string(chunks[pipeIdx+2].Data)
-						bap.SignerAddr = string(chunks[pipeIdx+3].Data)
-						if pipeIdx+4 < len(chunks) {
-							bap.Signature = string(chunks[pipeIdx+4].Data)
-							// Check if signer matches an ID pattern - would require additional context
-							bap.IsSignedByID = false // Default to false until we verify
-						}
-					}
+			// Look for AIP signature data
+			pipeIdx := -1
+			for i := 3; i < len(chunks); i++ {
+				if string(chunks[i].Data) == pipeSeparator {
+					pipeIdx = i
+					break
 				}
+			}
 
-			case REVOKE:
-				// REVOKE structure: REVOKE <txid> <sequence number>
-				if len(chunks) >= 3 {
-					bap.IDKey = string(chunks[1].Data) // TXID being revoked
-					bap.Sequence, _ = strconv.ParseUint(string(chunks[2].Data), 10, 64)
-
-					// Look for AIP signature data
-					pipeIdx := -1
-					for i := 3; i < len(chunks); i++ {
-						if string(chunks[i].Data) == pipeSeparator {
-							pipeIdx = i
-							break
-						}
-					}
-
-					if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
-						// AIP signature data found
-						bap.Algorithm = string(chunks[pipeIdx+2].Data)
-						bap.SignerAddr = string(chunks[pipeIdx+3].Data)
-						if pipeIdx+4 < len(chunks) {
-							bap.Signature = string(chunks[pipeIdx+4].Data)
-							// Check if signer matches an ID pattern - would require additional context
-							bap.IsSignedByID = false // Default to false until we verify
-						}
-					}
+			if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
+				// AIP signature data found
+				bap.Algorithm = string(chunks[pipeIdx+2].Data)
+				bap.SignerAddr = string(chunks[pipeIdx+3].Data)
+				if pipeIdx+4 < len(chunks) {
+					bap.Signature = string(chunks[pipeIdx+4].Data)
+					// Whether the signer is the identity's current address is tracked by BAPState.Accepted
+					bap.IsSignedByID = false
 				}
+			}
+		}
 
-			case ALIAS:
-				// ALIAS structure: ALIAS <alias> <address>
-				if len(chunks) >= 3 {
-					bap.IDKey = string(chunks[1].Data) // Alias
-					bap.Profile = chunks[2].Data
-
-					// Look for AIP signature data
-					pipeIdx := -1
-					for i := 3; i < len(chunks); i++ {
-						if string(chunks[i].Data) == pipeSeparator {
-							pipeIdx = i
-							break
-						}
-					}
-
-					if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
-						// AIP signature data found
-						bap.Algorithm = string(chunks[pipeIdx+2].Data)
-						bap.SignerAddr = string(chunks[pipeIdx+3].Data)
-						if pipeIdx+4 < len(chunks) {
-							bap.Signature = string(chunks[pipeIdx+4].Data)
-							// Check if signer matches an ID pattern - would require additional context
-							bap.IsSignedByID = false // Default to false until we verify
-						}
-					}
+	case ALIAS:
+		// ALIAS structure: ALIAS <alias> <address>
+		if len(chunks) >= 3 {
+			bap.IDKey = string(chunks[1].Data) // Alias
+			bap.Profile = chunks[2].Data
+
+			// Look for AIP signature data
+			pipeIdx := -1
+			for i := 3; i < len(chunks); i++ {
+				if string(chunks[i].Data) == pipeSeparator {
+					pipeIdx = i
+					break
 				}
 			}
 
-			return bap
+			if pipeIdx >= 0 && pipeIdx+3 < len(chunks) {
+				// AIP signature data found
+				bap.Algorithm = string(chunks[pipeIdx+2].Data)
+				bap.SignerAddr = string(chunks[pipeIdx+3].Data)
+				if pipeIdx+4 < len(chunks) {
+					bap.Signature = string(chunks[pipeIdx+4].Data)
+					// Whether the signer is the identity's current address is tracked by BAPState.Accepted
+					bap.IsSignedByID = false
+				}
+			}
+		}
+	}
+
+	// The AIP signing the record is normally the next protocol
+	if bap.Algorithm == "" && ii+1 < len(b.Protocols) {
+		if aip := decodeAIPAt(b, ii+1); aip != nil {
+			bap.Algorithm = aip.Algorithm
+			bap.SignerAddr = aip.Address
+			bap.Signature = base64.StdEncoding.EncodeToString(aip.Signature)
+			bap.Valid = aip.Valid
+			if bap.Type == ID {
+				// An ID is signed by the root address its identity key is derived from
+				bap.RootAddress = aip.Address
+				bap.IsSignedByID = aip.Valid && BAPIdentityKey(aip.Address) == bap.IDKey
+			}
 		}
 	}
 
+	return bap
+}
+
+// BAPIdentityKey returns the identity key derived from a root address:
+// base58(ripemd160(sha256(rootAddress)))
+func BAPIdentityKey(rootAddress string) string {
+	return base58.Encode(crypto.Ripemd160(crypto.Sha256([]byte(rootAddress))))
+}
+
+// NewBAPID creates an ID record, signed by the root key, that sets the current
+// signing address of the identity derived from the root key's address
+func NewBAPID(rootKey *ec.PrivateKey, address string) (*Bap, error) {
+	if rootKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	rootAddress, err := script.NewAddressFromPublicKey(rootKey.PubKey(), true)
+	if err != nil {
+		return nil, err
+	}
+	bap := &Bap{
+		Type:    ID,
+		IDKey:   BAPIdentityKey(rootAddress.AddressString),
+		Address: address,
+	}
+	if err := bap.sign(rootKey); err != nil {
+		return nil, err
+	}
+	bap.RootAddress = bap.SignerAddr
+	bap.IsSignedByID = true
+	return bap, nil
+}
+
+// NewBAPAttest creates an ATTEST record for an attestation hash, signed by the
+// identity's current signing key
+func NewBAPAttest(signingKey *ec.PrivateKey, attestationHash string, sequence uint64) (*Bap, error) {
+	bap := &Bap{
+		Type:     ATTEST,
+		IDKey:    attestationHash,
+		Sequence: sequence,
+	}
+	if err := bap.sign(signingKey); err != nil {
+		return nil, err
+	}
+	return bap, nil
+}
+
+// NewBAPRevoke creates a REVOKE record for an attestation hash, signed by the
+// identity's current signing key
+func NewBAPRevoke(signingKey *ec.PrivateKey, attestationHash string, sequence uint64) (*Bap, error) {
+	bap := &Bap{
+		Type:     REVOKE,
+		IDKey:    attestationHash,
+		Sequence: sequence,
+	}
+	if err := bap.sign(signingKey); err != nil {
+		return nil, err
+	}
+	return bap, nil
+}
+
+// NewBAPAlias creates an ALIAS record publishing a JSON profile for an identity,
+// signed by the identity's current signing key
+func NewBAPAlias(signingKey *ec.PrivateKey, idKey string, profile json.RawMessage) (*Bap, error) {
+	bap := &Bap{
+		Type:    ALIAS,
+		IDKey:   idKey,
+		Profile: profile,
+	}
+	if err := bap.sign(signingKey); err != nil {
+		return nil, err
+	}
+	return bap, nil
+}
+
+// sign signs the encoded record with an AIP signature and sets the AIP fields
+func (bap *Bap) sign(key *ec.PrivateKey) error {
+	p, err := bap.Encode()
+	if err != nil {
+		return err
+	}
+	aip, err := SignAIP(&Bitcom{Protocols: []*BitcomProtocol{p}}, key, AIPAlgoBitcoinECDSA)
+	if err != nil {
+		return err
+	}
+	bap.Algorithm = aip.Algorithm
+	bap.SignerAddr = aip.Address
+	bap.Signature = base64.StdEncoding.EncodeToString(aip.Signature)
+	bap.Valid = true
 	return nil
 }
+
+// Encode encodes the record, without its AIP signature, as a Bitcom protocol segment:
+//
+//	ID <identity key> <address>
+//	ATTEST <attestation hash> <sequence>
+//	REVOKE <attestation hash> <sequence>
+//	ALIAS <identity key> <profile JSON>
+func (bap *Bap) Encode() (*BitcomProtocol, error) {
+	if bap.IDKey == "" {
+		return nil, ErrMissingBAPIDKey
+	}
+	fields := [][]byte{[]byte(bap.Type), []byte(bap.IDKey)}
+	switch bap.Type {
+	case ID:
+		if bap.Address == "" {
+			return nil, ErrMissingBAPAddress
+		}
+		fields = append(fields, []byte(bap.Address))
+	case ATTEST, REVOKE:
+		fields = append(fields, []byte(strconv.FormatUint(bap.Sequence, 10)))
+	case ALIAS:
+		if !json.Valid(bap.Profile) {
+			return nil, ErrInvalidBAPProfile
+		}
+		fields = append(fields, bap.Profile)
+	default:
+		return nil, ErrUnknownBAPType
+	}
+	return newProtocol(BAPPrefix, fields...)
+}
+
+// Lock returns the OP_FALSE OP_RETURN locking script for the record, followed by
+// its AIP signature if it has one
+func (bap *Bap) Lock() (*script.Script, error) {
+	p, err := bap.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b := &Bitcom{
+		ScriptPrefix: []byte{script.OpFALSE},
+		Protocols:    []*BitcomProtocol{p},
+	}
+	if bap.Signature != "" {
+		sig, err := base64.StdEncoding.DecodeString(bap.Signature)
+		if err != nil {
+			return nil, err
+		}
+		aip := &AIP{
+			Algorithm: bap.Algorithm,
+			Address:   bap.SignerAddr,
+			Signature: sig,
+		}
+		if p, err = aip.Encode(); err != nil {
+			return nil, err
+		}
+		b.Protocols = append(b.Protocols, p)
+	}
+	return b.Lock(), nil
+}
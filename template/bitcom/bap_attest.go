@@ -0,0 +1,123 @@
+package bitcom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+)
+
+// BAPURNPrefix prefixes every BAP identity attribute URN
+const BAPURNPrefix = "urn:bap:id:"
+
+var (
+	ErrNotAttestation           = errors.New("BAP record is not an ATTEST")
+	ErrAttestationMismatch      = errors.New("attribute does not match the attestation hash")
+	ErrInvalidAttestationSig    = errors.New("attestation AIP signature is not valid")
+	ErrAttestationNotSignedBy   = errors.New("attestation is not signed by the identity")
+	ErrInvalidBAPURN            = errors.New("invalid BAP attribute URN")
+	ErrUnknownAttestingIdentity = errors.New("attesting identity not found")
+	ErrAttestationRevoked       = errors.New("attestation has been revoked")
+	ErrAttestationNotAccepted   = errors.New("attestation was not accepted by the BAP state")
+)
+
+// BAPAttributeURN returns the URN of an identity attribute:
+// urn:bap:id:<attribute>:<value>:<nonce>
+func BAPAttributeURN(attribute, value, nonce string) string {
+	return BAPURNPrefix + attribute + ":" + value + ":" + nonce
+}
+
+// ParseBAPAttributeURN splits an attribute URN into its attribute, value and nonce.
+// The value may contain colons; the attribute and nonce may not.
+func ParseBAPAttributeURN(urn string) (attribute, value, nonce string, err error) {
+	rest, ok := strings.CutPrefix(urn, BAPURNPrefix)
+	if !ok {
+		return "", "", "", ErrInvalidBAPURN
+	}
+	attribute, rest, ok = strings.Cut(rest, ":")
+	if !ok || attribute == "" {
+		return "", "", "", ErrInvalidBAPURN
+	}
+	sep := strings.LastIndex(rest, ":")
+	if sep < 0 || sep == len(rest)-1 {
+		return "", "", "", ErrInvalidBAPURN
+	}
+	return attribute, rest[:sep], rest[sep+1:], nil
+}
+
+// NewBAPNonce returns a random 32 byte hex nonce for an attribute URN
+func NewBAPNonce() (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// BAPAttributeHash returns the hex sha256 of an attribute URN
+func BAPAttributeHash(attribute, value, nonce string) string {
+	return hex.EncodeToString(crypto.Sha256([]byte(BAPAttributeURN(attribute, value, nonce))))
+}
+
+// BAPAttestationHash returns the hash attested to by an ATTEST record (its IDKey),
+// binding an attribute to the identity that holds it:
+// hex(sha256(attributeHash + idKey))
+func BAPAttestationHash(attribute, value, nonce, idKey string) string {
+	return hex.EncodeToString(crypto.Sha256([]byte(BAPAttributeHash(attribute, value, nonce) + idKey)))
+}
+
+// VerifyBAPAttestation checks that an ATTEST record attests to the attribute, value
+// and nonce of the identity idKey, and that it carries a valid AIP signature by
+// signerAddress
+func VerifyBAPAttestation(bap *Bap, attribute, value, nonce, idKey, signerAddress string) error {
+	if err := matchBAPAttestation(bap, attribute, value, nonce, idKey); err != nil {
+		return err
+	}
+	if bap.SignerAddr != signerAddress {
+		return ErrAttestationNotSignedBy
+	}
+	return nil
+}
+
+// VerifyAttestation checks that the ATTEST record in r attests to the attribute,
+// value and nonce of the identity idKey, that it was signed by the identity
+// attesterIDKey with the address that was current when r was mined, that r was
+// accepted when applied to s, and that the attester has not since revoked it
+func (s *BAPState) VerifyAttestation(r *BAPRecord, attribute, value, nonce, idKey, attesterIDKey string) error {
+	if r == nil {
+		return ErrNotAttestation
+	}
+	if err := matchBAPAttestation(r.Bap, attribute, value, nonce, idKey); err != nil {
+		return err
+	}
+	attester, ok := s.Identity(attesterIDKey)
+	if !ok {
+		return ErrUnknownAttestingIdentity
+	}
+	if !attester.SignedBy(r.Bap.SignerAddr, r.Height, r.Idx) {
+		return ErrAttestationNotSignedBy
+	}
+	if !s.Accepted(r) {
+		return ErrAttestationNotAccepted
+	}
+	if revoked, ok := attester.Revocations[r.Bap.IDKey]; ok && revoked.Sequence > r.Bap.Sequence {
+		return ErrAttestationRevoked
+	}
+	return nil
+}
+
+// matchBAPAttestation checks that bap is a validly signed ATTEST of the attribute
+func matchBAPAttestation(bap *Bap, attribute, value, nonce, idKey string) error {
+	if bap == nil || bap.Type != ATTEST {
+		return ErrNotAttestation
+	}
+	if bap.IDKey != BAPAttestationHash(attribute, value, nonce, idKey) {
+		return ErrAttestationMismatch
+	}
+	if !bap.Valid {
+		return ErrInvalidAttestationSig
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package bitcom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBAPAttributeURN verifies URN construction, parsing and hashing
+func TestBAPAttributeURN(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	const nonce = "e2c6fb4063cc04af58935737eaffc938011dff546d47b7fbb18ed346f8c4d4fa"
+	urn := BAPAttributeURN("name", "John Doe", nonce)
+	require.Equal(t, "urn:bap:id:name:John Doe:"+nonce, urn)
+
+	attribute, value, parsedNonce, err := ParseBAPAttributeURN(urn)
+	require.NoError(t, err)
+	require.Equal(t, "name", attribute)
+	require.Equal(t, "John Doe", value)
+	require.Equal(t, nonce, parsedNonce)
+
+	// Values may contain colons
+	_, value, _, err = ParseBAPAttributeURN(BAPAttributeURN("url", "https://example.com", nonce))
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", value)
+
+	for _, invalid := range []string{"", "urn:bap:id:", "urn:bap:id:name", "urn:bap:id:name:value:", "urn:other:name:value:nonce"} {
+		_, _, _, err = ParseBAPAttributeURN(invalid)
+		require.ErrorIs(t, err, ErrInvalidBAPURN, "%q should be invalid", invalid)
+	}
+
+	attributeHash := sha256.Sum256([]byte(urn))
+	require.Equal(t, hex.EncodeToString(attributeHash[:]), BAPAttributeHash("name", "John Doe", nonce))
+
+	const idKey = "37NZo9dpXFLGzaXY9zi5AQK9miLG"
+	attestationHash := sha256.Sum256([]byte(hex.EncodeToString(attributeHash[:]) + idKey))
+	require.Equal(t, hex.EncodeToString(attestationHash[:]), BAPAttestationHash("name", "John Doe", nonce, idKey))
+
+	n1, err := NewBAPNonce()
+	require.NoError(t, err)
+	n2, err := NewBAPNonce()
+	require.NoError(t, err)
+	require.Len(t, n1, 64)
+	require.NotEqual(t, n1, n2)
+}
+
+// TestVerifyBAPAttestation verifies attribute claims against ATTEST records
+func TestVerifyBAPAttestation(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	attesterRoot, attesterKey := newTestKey(t), newTestKey(t)
+	attesterAddress := testAddress(t, attesterKey)
+	const subject = "37NZo9dpXFLGzaXY9zi5AQK9miLG"
+	nonce, err := NewBAPNonce()
+	require.NoError(t, err)
+
+	attest, err := NewBAPAttest(attesterKey, BAPAttestationHash("email", "john@example.com", nonce, subject), 0)
+	require.NoError(t, err)
+
+	// Round trip through a script, as read from chain
+	s, err := attest.Lock()
+	require.NoError(t, err)
+	onChain := DecodeBAP(Decode(s))
+
+	require.NoError(t, VerifyBAPAttestation(onChain, "email", "john@example.com", nonce, subject, attesterAddress))
+	require.ErrorIs(t, VerifyBAPAttestation(onChain, "email", "jane@example.com", nonce, subject, attesterAddress), ErrAttestationMismatch)
+	require.ErrorIs(t, VerifyBAPAttestation(onChain, "email", "john@example.com", "other", subject, attesterAddress), ErrAttestationMismatch)
+	require.ErrorIs(t, VerifyBAPAttestation(onChain, "email", "john@example.com", nonce, "otherIdentity", attesterAddress), ErrAttestationMismatch)
+	require.ErrorIs(t, VerifyBAPAttestation(onChain, "email", "john@example.com", nonce, subject, testAddress(t, attesterRoot)), ErrAttestationNotSignedBy)
+	require.ErrorIs(t, VerifyBAPAttestation(&Bap{Type: ID}, "email", "john@example.com", nonce, subject, attesterAddress), ErrNotAttestation)
+
+	unsigned := *onChain
+	unsigned.Valid = false
+	require.ErrorIs(t, VerifyBAPAttestation(&unsigned, "email", "john@example.com", nonce, subject, attesterAddress), ErrInvalidAttestationSig)
+
+	// Against the attesting identity's state
+	id, err := NewBAPID(attesterRoot, attesterAddress)
+	require.NoError(t, err)
+	record := &BAPRecord{Bap: onChain, TxID: "b", Height: 101}
+	state := ResolveBAP([]*BAPRecord{{Bap: id, TxID: "a", Height: 100}, record}, nil)
+
+	require.NoError(t, state.VerifyAttestation(record, "email", "john@example.com", nonce, subject, id.IDKey))
+	require.ErrorIs(t, state.VerifyAttestation(record, "email", "john@example.com", nonce, subject, "unknown"), ErrUnknownAttestingIdentity)
+
+	early := &BAPRecord{Bap: onChain, TxID: "c", Height: 99}
+	require.ErrorIs(t, state.VerifyAttestation(early, "email", "john@example.com", nonce, subject, id.IDKey), ErrAttestationNotSignedBy, "The attester's address was not current yet")
+
+	// A copy of the record, or the record decoded again, is still accepted
+	copied := *record
+	copied.Bap = DecodeBAP(Decode(s))
+	require.NoError(t, state.VerifyAttestation(&copied, "email", "john@example.com", nonce, subject, id.IDKey))
+
+	// An attestation Apply rejected for replaying its sequence does not verify
+	replayed := &BAPRecord{Bap: onChain, TxID: "e", Height: 101, Idx: 1}
+	require.False(t, state.Apply(replayed))
+	require.ErrorIs(t, state.VerifyAttestation(replayed, "email", "john@example.com", nonce, subject, id.IDKey), ErrAttestationNotAccepted)
+
+	revoke, err := NewBAPRevoke(attesterKey, onChain.IDKey, 1)
+	require.NoError(t, err)
+	require.True(t, state.Apply(&BAPRecord{Bap: revoke, TxID: "d", Height: 102}))
+	require.ErrorIs(t, state.VerifyAttestation(record, "email", "john@example.com", nonce, subject, id.IDKey), ErrAttestationRevoked)
+}
@@ -0,0 +1,252 @@
+package bitcom
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+)
+
+// BAPRecord is a decoded BAP record and the position of the transaction that carried it
+type BAPRecord struct {
+	Bap    *Bap   `json:"bap"`
+	TxID   string `json:"txid"`
+	Height uint32 `json:"height"` // Block height, 0 if unconfirmed
+	Idx    uint64 `json:"idx"`    // Index of the transaction within the block
+	Vout   uint32 `json:"vout"`   // Output carrying the record
+}
+
+// key identifies the record by the output carrying it
+func (r *BAPRecord) key() bapRecordKey {
+	return bapRecordKey{TxID: r.TxID, Vout: r.Vout}
+}
+
+// bapRecordKey identifies a record by its txid and output, so a copy of a record or
+// the same record decoded again is recognised
+type bapRecordKey struct {
+	TxID string
+	Vout uint32
+}
+
+// BAPOrder compares two records, returning a negative number when a was applied before b
+type BAPOrder func(a, b *BAPRecord) int
+
+// BAPOrderByBlock orders records by block height, then index within the block.
+// Unconfirmed records are applied after all mined records and remaining ties are
+// broken by txid.
+func BAPOrderByBlock(a, b *BAPRecord) int {
+	if c := compareBlockPosition(a.Height, a.Idx, b.Height, b.Idx); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.TxID, b.TxID)
+}
+
+// BAPPosition is the position of the transaction that carried a BAP record
+type BAPPosition struct {
+	TxID   string `json:"txid"`
+	Height uint32 `json:"height"`
+	Idx    uint64 `json:"idx"`
+}
+
+// BAPAddress is a signing address of an identity and the range of blocks it was current for
+type BAPAddress struct {
+	Address string       `json:"address"`
+	From    BAPPosition  `json:"from"`            // ID record that set the address
+	Until   *BAPPosition `json:"until,omitempty"` // ID record that replaced the address, nil if current
+}
+
+// BAPAttestation is an attestation hash attested or revoked by an identity
+type BAPAttestation struct {
+	BAPPosition
+	Hash     string `json:"hash"`
+	Sequence uint64 `json:"sequence"`
+}
+
+// BAPIdentity is the state of an identity after applying its BAP records
+type BAPIdentity struct {
+	IDKey          string                     `json:"idKey"`
+	RootAddress    string                     `json:"rootAddress"`
+	CurrentAddress string                     `json:"currentAddress"`
+	Addresses      []*BAPAddress              `json:"addresses"` // Signing address history, oldest first
+	Attestations   map[string]*BAPAttestation `json:"attestations"`
+	Revocations    map[string]*BAPAttestation `json:"revocations"`
+	Profile        json.RawMessage            `json:"profile,omitempty"` // Latest ALIAS profile
+}
+
+// SignedBy reports whether address was the identity's signing address for a
+// transaction at the given block height and index
+func (id *BAPIdentity) SignedBy(address string, height uint32, idx uint64) bool {
+	for _, a := range id.Addresses {
+		if a.Address != address {
+			continue
+		}
+		if compareBlockPosition(height, idx, a.From.Height, a.From.Idx) < 0 {
+			continue
+		}
+		if a.Until == nil || compareBlockPosition(height, idx, a.Until.Height, a.Until.Idx) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// BAPState is the state of every identity after applying a sequence of BAP records
+type BAPState struct {
+	Identities map[string]*BAPIdentity `json:"identities"` // Keyed by identity key
+	current    map[string]*BAPIdentity // Keyed by current signing address, held by one identity at a time
+	accepted   map[bapRecordKey]bool   // Records accepted by Apply, keyed by txid and output
+}
+
+// NewBAPState creates an empty BAP state
+func NewBAPState() *BAPState {
+	return &BAPState{
+		Identities: make(map[string]*BAPIdentity),
+		current:    make(map[string]*BAPIdentity),
+		accepted:   make(map[bapRecordKey]bool),
+	}
+}
+
+// ResolveBAP sorts records with order (BAPOrderByBlock if nil) and applies them
+// to an empty state. Nil records are skipped, so order is never called with nil.
+// Neither the records slice nor the records are modified.
+func ResolveBAP(records []*BAPRecord, order BAPOrder) *BAPState {
+	if order == nil {
+		order = BAPOrderByBlock
+	}
+	sorted := slices.DeleteFunc(slices.Clone(records), func(r *BAPRecord) bool { return r == nil })
+	slices.SortStableFunc(sorted, order)
+
+	state := NewBAPState()
+	for _, r := range sorted {
+		state.Apply(r)
+	}
+	return state
+}
+
+// Apply applies a single record to the state and reports whether it was accepted.
+// Records must be applied in order. Records with an invalid AIP signature are
+// ignored, as are:
+//   - ID records not signed by the root address of their identity key
+//   - ID records setting an address that is current for another identity
+//   - ATTEST, REVOKE and ALIAS records not signed by the current address of an identity
+//   - ATTEST and REVOKE records whose sequence does not increase for the hash
+//
+// The record is not modified; use Accepted to look up whether it was accepted.
+func (s *BAPState) Apply(r *BAPRecord) bool {
+	if r == nil || r.Bap == nil || !r.Bap.Valid {
+		return false
+	}
+	if s.apply(r) {
+		s.accepted[r.key()] = true
+		return true
+	}
+	return false
+}
+
+// Accepted reports whether the record carried by the same txid and output as r
+// was accepted when applied to the state
+func (s *BAPState) Accepted(r *BAPRecord) bool {
+	return r != nil && s.accepted[r.key()]
+}
+
+// apply applies a record with a valid signature and reports whether it was accepted
+func (s *BAPState) apply(r *BAPRecord) bool {
+	bap := r.Bap
+	pos := BAPPosition{TxID: r.TxID, Height: r.Height, Idx: r.Idx}
+
+	if bap.Type == ID {
+		if BAPIdentityKey(bap.SignerAddr) != bap.IDKey || bap.Address == "" {
+			return false
+		}
+		id, ok := s.Identities[bap.IDKey]
+		if holder, held := s.current[bap.Address]; held && holder != id {
+			// An address signs for a single identity at a time
+			return false
+		}
+		if !ok {
+			id = &BAPIdentity{
+				IDKey:        bap.IDKey,
+				RootAddress:  bap.SignerAddr,
+				Attestations: make(map[string]*BAPAttestation),
+				Revocations:  make(map[string]*BAPAttestation),
+			}
+			s.Identities[bap.IDKey] = id
+		}
+		if bap.Address != id.CurrentAddress {
+			if len(id.Addresses) > 0 {
+				id.Addresses[len(id.Addresses)-1].Until = &pos
+				delete(s.current, id.CurrentAddress)
+			}
+			id.Addresses = append(id.Addresses, &BAPAddress{
+				Address: bap.Address,
+				From:    pos,
+			})
+			id.CurrentAddress = bap.Address
+			s.current[bap.Address] = id
+		}
+		return true
+	}
+
+	id, ok := s.current[bap.SignerAddr]
+	if !ok {
+		return false
+	}
+	att := &BAPAttestation{
+		BAPPosition: pos,
+		Hash:        bap.IDKey,
+		Sequence:    bap.Sequence,
+	}
+	switch bap.Type {
+	case ATTEST:
+		if !id.nextSequence(att) {
+			return false
+		}
+		delete(id.Revocations, att.Hash)
+		id.Attestations[att.Hash] = att
+	case REVOKE:
+		if !id.nextSequence(att) {
+			return false
+		}
+		delete(id.Attestations, att.Hash)
+		id.Revocations[att.Hash] = att
+	case ALIAS:
+		if bap.IDKey != id.IDKey {
+			return false
+		}
+		id.Profile = bap.Profile
+	default:
+		return false
+	}
+	return true
+}
+
+// nextSequence reports whether att follows the last attestation or revocation of its hash
+func (id *BAPIdentity) nextSequence(att *BAPAttestation) bool {
+	for _, prev := range []*BAPAttestation{id.Attestations[att.Hash], id.Revocations[att.Hash]} {
+		if prev != nil && att.Sequence <= prev.Sequence {
+			return false
+		}
+	}
+	return true
+}
+
+// Identity returns the identity with the given identity key
+func (s *BAPState) Identity(idKey string) (*BAPIdentity, bool) {
+	id, ok := s.Identities[idKey]
+	return id, ok
+}
+
+// IdentityByAddress returns the identity whose current signing address is address
+func (s *BAPState) IdentityByAddress(address string) (*BAPIdentity, bool) {
+	id, ok := s.current[address]
+	return id, ok
+}
+
+// IsSignedByIdentity reports whether a valid AIP signature, carried by a transaction
+// at the given block height and index, was made by the identity with key idKey
+func (s *BAPState) IsSignedByIdentity(idKey string, aip *AIP, height uint32, idx uint64) bool {
+	id, ok := s.Identities[idKey]
+	if !ok || aip == nil || !aip.Valid {
+		return false
+	}
+	return id.SignedBy(aip.Address, height, idx)
+}
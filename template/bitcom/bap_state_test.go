@@ -0,0 +1,204 @@
+package bitcom
+
+import (
+	"encoding/json"
+	"testing"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveBAP verifies that ID rotations, attestations and revocations are
+// replayed in block order and only accepted from the current signing address
+func TestResolveBAP(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	rootKey, firstKey, secondKey := newTestKey(t), newTestKey(t), newTestKey(t)
+	firstAddress, secondAddress := testAddress(t, firstKey), testAddress(t, secondKey)
+
+	idFirst, err := NewBAPID(rootKey, firstAddress)
+	require.NoError(t, err)
+	idSecond, err := NewBAPID(rootKey, secondAddress)
+	require.NoError(t, err)
+	attestA, err := NewBAPAttest(firstKey, "hashA", 0)
+	require.NoError(t, err)
+	staleAttestB, err := NewBAPAttest(firstKey, "hashB", 0)
+	require.NoError(t, err)
+	attestB, err := NewBAPAttest(secondKey, "hashB", 0)
+	require.NoError(t, err)
+	revokeA, err := NewBAPRevoke(secondKey, "hashA", 1)
+	require.NoError(t, err)
+	replayRevokeB, err := NewBAPRevoke(secondKey, "hashB", 0)
+	require.NoError(t, err)
+	alias, err := NewBAPAlias(secondKey, idFirst.IDKey, json.RawMessage(`{"name":"Satoshi"}`))
+	require.NoError(t, err)
+
+	// Deliberately out of order, with a nil record
+	records := []*BAPRecord{
+		{Bap: alias, TxID: "h", Height: 106},
+		{Bap: replayRevokeB, TxID: "g", Height: 105, Idx: 2},
+		{Bap: revokeA, TxID: "f", Height: 105, Idx: 1},
+		{Bap: attestB, TxID: "e", Height: 104},
+		nil,
+		{Bap: staleAttestB, TxID: "d", Height: 103},
+		{Bap: idSecond, TxID: "c", Height: 102},
+		{Bap: attestA, TxID: "b", Height: 101},
+		{Bap: idFirst, TxID: "a", Height: 100},
+	}
+	state := ResolveBAP(records, nil)
+
+	id, ok := state.Identity(idFirst.IDKey)
+	require.True(t, ok, "Identity should be resolved")
+	require.Equal(t, testAddress(t, rootKey), id.RootAddress)
+	require.Equal(t, secondAddress, id.CurrentAddress)
+	require.JSONEq(t, `{"name":"Satoshi"}`, string(id.Profile))
+
+	// Address history
+	require.Len(t, id.Addresses, 2)
+	require.Equal(t, firstAddress, id.Addresses[0].Address)
+	require.Equal(t, BAPPosition{TxID: "a", Height: 100}, id.Addresses[0].From)
+	require.Equal(t, &BAPPosition{TxID: "c", Height: 102}, id.Addresses[0].Until)
+	require.Equal(t, secondAddress, id.Addresses[1].Address)
+	require.Nil(t, id.Addresses[1].Until, "Current address should not have an end")
+
+	// hashB is attested by the current key; the stale attestation and replayed revocation are ignored
+	require.Len(t, id.Attestations, 1)
+	require.Equal(t, "e", id.Attestations["hashB"].TxID)
+	require.Len(t, id.Revocations, 1)
+	require.Equal(t, uint64(1), id.Revocations["hashA"].Sequence)
+
+	require.True(t, state.Accepted(records[3]), "Records signed by the current key are accepted")
+	require.False(t, state.Accepted(records[5]), "Records signed by a rotated key should not be accepted")
+	require.False(t, state.Accepted(records[1]), "Records with a replayed sequence should not be accepted")
+
+	// Resolving the same records again is not affected by the first run
+	require.False(t, attestB.IsSignedByID, "Records are not modified")
+	partial := ResolveBAP(records[:4], nil)
+	require.False(t, partial.Accepted(records[3]), "Without the ID records nothing is accepted")
+	require.True(t, state.Accepted(records[3]))
+
+	byAddress, ok := state.IdentityByAddress(secondAddress)
+	require.True(t, ok)
+	require.Same(t, id, byAddress)
+	_, ok = state.IdentityByAddress(firstAddress)
+	require.False(t, ok, "Rotated addresses are no longer current")
+}
+
+// TestBAPState_IsSignedByIdentity verifies that AIP signatures are attributed to an
+// identity only while the signing address was current
+func TestBAPState_IsSignedByIdentity(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	rootKey, firstKey, secondKey := newTestKey(t), newTestKey(t), newTestKey(t)
+	firstAddress, secondAddress := testAddress(t, firstKey), testAddress(t, secondKey)
+
+	idFirst, err := NewBAPID(rootKey, firstAddress)
+	require.NoError(t, err)
+	idSecond, err := NewBAPID(rootKey, secondAddress)
+	require.NoError(t, err)
+	state := ResolveBAP([]*BAPRecord{
+		{Bap: idFirst, TxID: "a", Height: 100, Idx: 5},
+		{Bap: idSecond, TxID: "b", Height: 200, Idx: 5},
+	}, nil)
+
+	first := &AIP{Address: firstAddress, Valid: true}
+	second := &AIP{Address: secondAddress, Valid: true}
+
+	tests := []struct {
+		name     string
+		aip      *AIP
+		height   uint32
+		idx      uint64
+		expected bool
+	}{
+		{"before the identity existed", first, 99, 0, false},
+		{"first key after it was set", first, 100, 6, true},
+		{"first key before rotation", first, 200, 4, true},
+		{"first key after rotation", first, 200, 6, false},
+		{"second key before rotation", second, 150, 0, false},
+		{"second key after rotation", second, 300, 0, true},
+		{"second key unconfirmed", second, 0, 0, true},
+		{"invalid signature", &AIP{Address: secondAddress}, 300, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, state.IsSignedByIdentity(idFirst.IDKey, tt.aip, tt.height, tt.idx))
+		})
+	}
+
+	require.False(t, state.IsSignedByIdentity("unknown", second, 300, 0))
+}
+
+// TestBAPState_RejectsUnsignedID verifies that ID records must be signed by the
+// root address of their identity key
+func TestBAPState_RejectsUnsignedID(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	rootKey, otherKey := newTestKey(t), newTestKey(t)
+	victim, err := NewBAPID(rootKey, testAddress(t, rootKey))
+	require.NoError(t, err)
+
+	// A valid signature by a key that does not own the identity key
+	forged, err := NewBAPID(otherKey, testAddress(t, otherKey))
+	require.NoError(t, err)
+	forged.IDKey = victim.IDKey
+
+	state := NewBAPState()
+	require.False(t, state.Apply(&BAPRecord{Bap: forged, Height: 1}))
+	require.False(t, state.Apply(&BAPRecord{Bap: &Bap{Type: ID, IDKey: victim.IDKey, Address: "1A"}, Height: 1}), "Unsigned records are ignored")
+	require.True(t, state.Apply(&BAPRecord{Bap: victim, Height: 2}))
+	require.Len(t, state.Identities, 1)
+}
+
+// TestBAPState_SharedAddress verifies that an address signs for one identity at a
+// time, so rotating one identity does not affect another
+func TestBAPState_SharedAddress(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	aliceRoot, bobRoot, shared, next := newTestKey(t), newTestKey(t), newTestKey(t), newTestKey(t)
+	aliceID, err := NewBAPID(aliceRoot, testAddress(t, shared))
+	require.NoError(t, err)
+	bobID, err := NewBAPID(bobRoot, testAddress(t, shared))
+	require.NoError(t, err)
+	aliceNext, err := NewBAPID(aliceRoot, testAddress(t, next))
+	require.NoError(t, err)
+	bobRetry, err := NewBAPID(bobRoot, testAddress(t, shared))
+	require.NoError(t, err)
+
+	state := NewBAPState()
+	require.True(t, state.Apply(&BAPRecord{Bap: aliceID, TxID: "a", Height: 100}))
+	require.False(t, state.Apply(&BAPRecord{Bap: bobID, TxID: "b", Height: 101}), "The address is current for another identity")
+	_, ok := state.Identity(bobID.IDKey)
+	require.False(t, ok, "A rejected ID record does not create an identity")
+
+	// Once alice rotates away, the address is free for bob
+	require.True(t, state.Apply(&BAPRecord{Bap: aliceNext, TxID: "c", Height: 102}))
+	require.True(t, state.Apply(&BAPRecord{Bap: bobRetry, TxID: "d", Height: 103}))
+	holder, ok := state.IdentityByAddress(testAddress(t, shared))
+	require.True(t, ok)
+	require.Equal(t, bobID.IDKey, holder.IDKey)
+	holder, ok = state.IdentityByAddress(testAddress(t, next))
+	require.True(t, ok)
+	require.Equal(t, aliceID.IDKey, holder.IDKey)
+}
+
+// newTestKey creates a random private key
+func newTestKey(t *testing.T) *ec.PrivateKey {
+	t.Helper()
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	return key
+}
+
+// testAddress returns the compressed address of a key
+func testAddress(t *testing.T, key *ec.PrivateKey) string {
+	t.Helper()
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	require.NoError(t, err)
+	return address.AddressString
+}
@@ -1,12 +1,14 @@
 package bitcom
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/stretchr/testify/assert"
@@ -319,3 +321,97 @@ func TestCreateBAP(t *testing.T) {
 	assert.Equal(t, txid, attestBap.IDKey)
 	assert.Equal(t, uint64(seqNum), attestBap.Sequence)
 }
+
+// TestDecodeBAP_IDSignature verifies that the AIP following a real ID record is
+// attached and checked against the identity key
+func TestDecodeBAP_IDSignature(t *testing.T) {
+	hexBytes, err := os.ReadFile(filepath.Join("testdata", "c2f0f5f503c012737a8ee0dfa2ae40f52177338fd746afccdd992b0e165af6f9.hex"))
+	require.NoError(t, err)
+	tx, err := transaction.NewTransactionFromHex(strings.TrimSpace(string(hexBytes)))
+	require.NoError(t, err)
+
+	bap := DecodeBAP(Decode(tx.Outputs[0].LockingScript))
+	require.NotNil(t, bap)
+	require.Equal(t, ID, bap.Type)
+	require.Equal(t, "14Y7ytoMXYFyz6Fmx2ykSa9Fhrnz7RCjvN", bap.RootAddress)
+	require.Equal(t, bap.RootAddress, bap.SignerAddr)
+	require.Equal(t, BAPIdentityKey(bap.RootAddress), bap.IDKey)
+	require.True(t, bap.IsSignedByID, "ID should be signed by its root address")
+}
+
+// TestNewBAP verifies that each BAP record type is signed by the given key and
+// decodes back identically
+func TestNewBAP(t *testing.T) {
+	rootKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	signingKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	rootAddress, err := script.NewAddressFromPublicKey(rootKey.PubKey(), true)
+	require.NoError(t, err)
+	signingAddress, err := script.NewAddressFromPublicKey(signingKey.PubKey(), true)
+	require.NoError(t, err)
+	idKey := BAPIdentityKey(rootAddress.AddressString)
+
+	id, err := NewBAPID(rootKey, signingAddress.AddressString)
+	require.NoError(t, err)
+	attest, err := NewBAPAttest(signingKey, "d4bd8b4a7a5a1b1f4a8de6e2b1c9f8e0e0ff3c1e2a5a8b9c0d1e2f3a4b5c6d7e", 0)
+	require.NoError(t, err)
+	revoke, err := NewBAPRevoke(signingKey, "d4bd8b4a7a5a1b1f4a8de6e2b1c9f8e0e0ff3c1e2a5a8b9c0d1e2f3a4b5c6d7e", 1)
+	require.NoError(t, err)
+	alias, err := NewBAPAlias(signingKey, idKey, json.RawMessage(`{"@type":"Person","name":"Satoshi"}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		bap    *Bap
+		signer string
+	}{
+		{"ID", id, rootAddress.AddressString},
+		{"ATTEST", attest, signingAddress.AddressString},
+		{"REVOKE", revoke, signingAddress.AddressString},
+		{"ALIAS", alias, signingAddress.AddressString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.signer, tt.bap.SignerAddr)
+
+			s, err := tt.bap.Lock()
+			require.NoError(t, err)
+			t.Logf("BAP %s script: %x", tt.name, s.Bytes())
+
+			bc := Decode(s)
+			require.Equal(t, tt.bap, DecodeBAP(bc), "Record should decode back identically")
+
+			aips := DecodeAIP(bc)
+			require.Len(t, aips, 1)
+			require.True(t, aips[0].Valid, "AIP signature should be valid")
+			require.Equal(t, tt.signer, aips[0].Address)
+		})
+	}
+
+	require.Equal(t, idKey, id.IDKey)
+	require.Equal(t, rootAddress.AddressString, id.RootAddress)
+	require.True(t, id.IsSignedByID)
+}
+
+// TestBAPEncode_Errors verifies that incomplete records are rejected
+func TestBAPEncode_Errors(t *testing.T) {
+	_, err := (&Bap{Type: "UNKNOWN", IDKey: "key"}).Encode()
+	require.ErrorIs(t, err, ErrUnknownBAPType)
+
+	_, err = (&Bap{Type: ATTEST}).Encode()
+	require.ErrorIs(t, err, ErrMissingBAPIDKey)
+
+	_, err = (&Bap{Type: ID, IDKey: "key"}).Encode()
+	require.ErrorIs(t, err, ErrMissingBAPAddress)
+
+	_, err = (&Bap{Type: ALIAS, IDKey: "key", Profile: json.RawMessage("{")}).Encode()
+	require.ErrorIs(t, err, ErrInvalidBAPProfile)
+
+	_, err = NewBAPID(nil, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa")
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+
+	_, err = NewBAPAttest(nil, "hash", 0)
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+}
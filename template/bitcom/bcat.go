@@ -0,0 +1,238 @@
+package bitcom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bitcoin-sv/go-templates/lib"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/util"
+)
+
+// BCAT PROTOCOL - splits files too large for a single output across multiple transactions
+//
+// Linker: PREFIX INFO MEDIA_TYPE ENCODING FILENAME FLAG TXID1 [TXID2 ...]
+// Part:   PREFIX DATA
+
+// BCATPrefix is the bitcom protocol prefix for the BCAT linker
+const BCATPrefix = "15DHFxWZJT58f9nhyGnsRBqrgwK4W6h4Up"
+
+// BCATPartPrefix is the bitcom protocol prefix for a BCAT part
+const BCATPartPrefix = "1ChDHzdd1H4wSjgGMHyndZm6qxEDGjqpJL"
+
+var (
+	ErrNoBCATParts      = errors.New("BCAT has no parts")
+	ErrInvalidPartSize  = errors.New("BCAT part size must be positive")
+	ErrEmptyBCATData    = errors.New("BCAT data is empty")
+	ErrBCATPartNotFound = errors.New("BCAT part not found in transaction")
+)
+
+// BCAT represents a BCAT linker, which references the part transactions that
+// together make up a file. Empty fields are encoded as OP_0.
+type BCAT struct {
+	Info      string           `json:"info"`
+	MediaType MediaType        `json:"mediaType"`
+	Encoding  Encoding         `json:"encoding"`
+	Filename  string           `json:"filename"`
+	Flag      string           `json:"flag"`
+	Parts     []chainhash.Hash `json:"parts"` // Part txids, in order
+}
+
+// BCATPart represents a single BCAT part
+type BCATPart struct {
+	Data []byte `json:"data"`
+}
+
+// DecodeBCAT processes and extracts a BCAT linker from a transaction script.
+// Returns nil if the script is invalid, has no parts, or a part is not a 32 byte txid.
+func DecodeBCAT(data any) *BCAT {
+	return decodeBCAT(ToScript(data), ignoreWarning)
+}
+
+// decodeBCATAt decodes the BCAT linker at index idx of the Bitcom, recording why
+// it could not be decoded as a warning
+func decodeBCATAt(b *Bitcom, idx int) *BCAT {
+	return decodeBCAT(ToScript(b.Protocols[idx].Script), b.warnAt(idx))
+}
+
+// bcatFields names the fields of a BCAT linker that precede its part txids
+var bcatFields = [5]string{"BCAT info", "BCAT media type", "BCAT encoding", "BCAT filename", "BCAT flag"}
+
+// decodeBCAT decodes a BCAT linker from scr, reporting problems to warn
+func decodeBCAT(scr *script.Script, warn warnFunc) *BCAT {
+	if scr == nil {
+		return nil
+	}
+
+	pos := ZERO
+	var fields [5]string
+	for i := range fields {
+		op, err := readField(scr, &pos, bcatFields[i], warn)
+		if err != nil {
+			return nil
+		}
+		fields[i] = string(op.Data)
+	}
+
+	bc := &BCAT{
+		Info:      fields[0],
+		MediaType: MediaType(fields[1]),
+		Encoding:  Encoding(fields[2]),
+		Filename:  fields[3],
+		Flag:      fields[4],
+	}
+
+	// Txids are pushed in display (big-endian) byte order
+	for pos < len(*scr) {
+		start := pos
+		op, err := readField(scr, &pos, "BCAT part txid", warn)
+		if err != nil {
+			return nil
+		}
+		if len(op.Data) != chainhash.HashSize {
+			warn(start, fmt.Sprintf("BCAT part txid is %d bytes, not %d", len(op.Data), chainhash.HashSize))
+			return nil
+		}
+		txid, err := chainhash.NewHash(util.ReverseBytes(op.Data))
+		if err != nil {
+			return nil
+		}
+		bc.Parts = append(bc.Parts, *txid)
+	}
+	if len(bc.Parts) == 0 {
+		warn(pos, "missing BCAT part txid")
+		return nil
+	}
+
+	return bc
+}
+
+// Encode encodes the linker as a Bitcom protocol segment
+func (bc *BCAT) Encode() (*BitcomProtocol, error) {
+	if len(bc.Parts) == 0 {
+		return nil, ErrNoBCATParts
+	}
+	fields := [][]byte{
+		[]byte(bc.Info),
+		[]byte(bc.MediaType),
+		[]byte(bc.Encoding),
+		[]byte(bc.Filename),
+		[]byte(bc.Flag),
+	}
+	for _, txid := range bc.Parts {
+		fields = append(fields, util.ReverseBytes(txid.CloneBytes()))
+	}
+	return newProtocol(BCATPrefix, fields...)
+}
+
+// AddParts appends the txids of part transactions to the linker. Parts must be
+// added after they are funded and signed, as that changes their txids.
+func (bc *BCAT) AddParts(txs ...*transaction.Transaction) {
+	for _, tx := range txs {
+		bc.Parts = append(bc.Parts, *tx.TxID())
+	}
+}
+
+// Assemble loads every part from src and concatenates their data in order
+func (bc *BCAT) Assemble(ctx context.Context, src lib.TxSource) ([]byte, error) {
+	if src == nil {
+		return nil, lib.ErrNoTxSource
+	}
+	if len(bc.Parts) == 0 {
+		return nil, ErrNoBCATParts
+	}
+	var data []byte
+	for i := range bc.Parts {
+		tx, err := src.LoadTx(ctx, &bc.Parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("loading BCAT part %d %s: %w", i, bc.Parts[i], err)
+		}
+		part := FindBCATPart(tx)
+		if part == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBCATPartNotFound, bc.Parts[i])
+		}
+		data = append(data, part.Data...)
+	}
+	return data, nil
+}
+
+// DecodeBCATPart processes and extracts a BCAT part from a transaction script.
+// Returns nil if the script is invalid or cannot be parsed.
+func DecodeBCATPart(data any) *BCATPart {
+	return decodeBCATPart(ToScript(data), ignoreWarning)
+}
+
+// decodeBCATPartAt decodes the BCAT part at index idx of the Bitcom, recording why
+// it could not be decoded as a warning
+func decodeBCATPartAt(b *Bitcom, idx int) *BCATPart {
+	return decodeBCATPart(ToScript(b.Protocols[idx].Script), b.warnAt(idx))
+}
+
+// decodeBCATPart decodes a BCAT part from scr, reporting problems to warn
+func decodeBCATPart(scr *script.Script, warn warnFunc) *BCATPart {
+	if scr == nil {
+		return nil
+	}
+
+	pos := ZERO
+	op, err := readField(scr, &pos, "BCAT part data", warn)
+	if err != nil {
+		return nil
+	}
+	return &BCATPart{Data: op.Data}
+}
+
+// Encode encodes the part as a Bitcom protocol segment
+func (p *BCATPart) Encode() (*BitcomProtocol, error) {
+	return newProtocol(BCATPartPrefix, p.Data)
+}
+
+// FindBCATPart returns the first BCAT part in the outputs of tx, or nil if there is none
+func FindBCATPart(tx *transaction.Transaction) *BCATPart {
+	for _, output := range tx.Outputs {
+		bc := Decode(output.LockingScript)
+		if bc == nil {
+			continue
+		}
+		for _, proto := range bc.Protocols {
+			if proto.Protocol != BCATPartPrefix {
+				continue
+			}
+			if part := DecodeBCATPart(proto.Script); part != nil {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// SplitBCAT splits data into parts of at most partSize bytes and returns one
+// transaction per part, each with a single zero satoshi OP_RETURN output.
+// Fund and sign the transactions before referencing them with AddParts.
+func SplitBCAT(data []byte, partSize int) ([]*transaction.Transaction, error) {
+	if partSize <= 0 {
+		return nil, ErrInvalidPartSize
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyBCATData
+	}
+
+	txs := make([]*transaction.Transaction, 0, (len(data)+partSize-1)/partSize)
+	for start := 0; start < len(data); start += partSize {
+		end := min(start+partSize, len(data))
+		s, err := NewBuilder().Add(&BCATPart{Data: data[start:end]}).Build()
+		if err != nil {
+			return nil, err
+		}
+		tx := transaction.NewTransaction()
+		tx.AddOutput(&transaction.TransactionOutput{
+			LockingScript: s,
+			Satoshis:      0,
+		})
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,133 @@
+package bitcom
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bitcoin-sv/go-templates/lib"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBCAT_SplitAssemble verifies that a file split into parts is reassembled from a TxSource
+func TestBCAT_SplitAssemble(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	file := bytes.Repeat([]byte("0123456789"), 25)
+
+	parts, err := SplitBCAT(file, 100)
+	require.NoError(t, err)
+	require.Len(t, parts, 3, "250 bytes should split into 3 parts of up to 100 bytes")
+
+	for i, tx := range parts {
+		require.Len(t, tx.Outputs, 1)
+		require.Zero(t, tx.Outputs[0].Satoshis)
+		part, ok := FirstValue[*BCATPart](DecodeAll(tx.Outputs[0].LockingScript))
+		require.True(t, ok, "Part %d should decode", i)
+		require.Equal(t, file[i*100:min((i+1)*100, len(file))], part.Data)
+	}
+
+	linker := &BCAT{
+		Info:      "bcat",
+		MediaType: MediaTypeTextPlain,
+		Encoding:  EncodingBinary,
+		Filename:  "digits.txt",
+	}
+	linker.AddParts(parts...)
+
+	s, err := NewBuilder().Add(linker).Build()
+	require.NoError(t, err)
+
+	decoded, ok := FirstValue[*BCAT](DecodeAll(s))
+	require.True(t, ok, "Linker should decode")
+	require.Equal(t, linker, decoded)
+
+	src := lib.NewMemoryTxSource(parts...)
+	assembled, err := decoded.Assemble(context.Background(), src)
+	require.NoError(t, err)
+	require.Equal(t, file, assembled)
+}
+
+// TestBCAT_Assemble_Errors verifies missing sources, transactions and parts are reported
+func TestBCAT_Assemble_Errors(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	parts, err := SplitBCAT([]byte("Hello world"), 5)
+	require.NoError(t, err)
+
+	linker := &BCAT{}
+	linker.AddParts(parts...)
+
+	_, err = linker.Assemble(context.Background(), nil)
+	require.ErrorIs(t, err, lib.ErrNoTxSource)
+
+	_, err = linker.Assemble(context.Background(), lib.NewMemoryTxSource(parts[0], parts[2]))
+	require.ErrorIs(t, err, lib.ErrTxNotFound, "Missing part transactions should be reported")
+
+	// A transaction without a BCAT part
+	other := transaction.NewTransaction()
+	otherScript, err := NewBuilder().AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).Build()
+	require.NoError(t, err)
+	other.AddOutput(&transaction.TransactionOutput{LockingScript: otherScript})
+	linker.AddParts(other)
+
+	_, err = linker.Assemble(context.Background(), lib.NewMemoryTxSource(append(parts, other)...))
+	require.ErrorIs(t, err, ErrBCATPartNotFound)
+
+	_, err = (&BCAT{}).Assemble(context.Background(), lib.NewMemoryTxSource())
+	require.ErrorIs(t, err, ErrNoBCATParts)
+}
+
+// TestBCAT_Encode verifies the linker layout, txid byte order and validation
+func TestBCAT_Encode(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	const txidHex = "a7a2632627a7e19aef35c8110758b05c1cc14ffb9bc3df54092f5b81f9799d37"
+	txid, err := chainhash.NewHashFromHex(txidHex)
+	require.NoError(t, err)
+	txidBytes, err := hex.DecodeString(txidHex)
+	require.NoError(t, err)
+
+	linker := &BCAT{
+		MediaType: MediaTypeImagePNG,
+		Encoding:  EncodingBinary,
+		Parts:     []chainhash.Hash{*txid},
+	}
+	s, err := NewBuilder().Add(linker).Build()
+	require.NoError(t, err)
+
+	// Empty fields are OP_0 and txids are pushed as displayed
+	expected := &script.Script{}
+	_ = expected.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = expected.AppendPushDataString(BCATPrefix)
+	_ = expected.AppendOpcodes(script.OpFALSE)
+	_ = expected.AppendPushDataString(string(MediaTypeImagePNG))
+	_ = expected.AppendPushDataString(string(EncodingBinary))
+	_ = expected.AppendOpcodes(script.OpFALSE, script.OpFALSE)
+	_ = expected.AppendPushData(txidBytes)
+	require.Equal(t, expected.Bytes(), s.Bytes())
+
+	_, err = (&BCAT{}).Encode()
+	require.ErrorIs(t, err, ErrNoBCATParts)
+
+	_, err = SplitBCAT([]byte("data"), 0)
+	require.ErrorIs(t, err, ErrInvalidPartSize)
+
+	_, err = SplitBCAT(nil, 10)
+	require.ErrorIs(t, err, ErrEmptyBCATData)
+
+	// A part reference that is not a txid is rejected
+	invalid := &script.Script{}
+	for _, field := range []string{"bcat", "text/plain", "utf-8", "", "", "not a txid"} {
+		_ = invalid.AppendPushDataString(field)
+	}
+	require.Nil(t, DecodeBCAT(invalid))
+	require.Nil(t, DecodeBCAT(nil))
+}
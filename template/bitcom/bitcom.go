@@ -7,6 +7,7 @@ import (
 type Bitcom struct {
 	Protocols    []*BitcomProtocol `json:"protos"`
 	ScriptPrefix []byte            `json:"prefix,omitempty"`
+	Warnings     []*DecodeWarning  `json:"warnings,omitempty"` // Problems found while decoding, in the order found
 }
 type BitcomProtocol struct {
 	Protocol string `json:"proto"`
@@ -22,13 +23,18 @@ func Decode(scr *script.Script) (bitcom *Bitcom) {
 		}
 	}
 
-	pos := findReturn(scr, 0)
+	pos, prev := findReturnOp(scr, 0)
 	if pos == -1 {
 		return
 	}
 	var prefix []byte
 	if pos > 0 {
-		prefix = (*scr)[:pos-1]
+		// An OP_FALSE before the OP_RETURN belongs to the data carrier. A plain
+		// OP_RETURN follows an executable prefix, which is kept whole.
+		prefix = (*scr)[:pos]
+		if prev != nil && prev.Op == script.OpFALSE {
+			prefix = (*scr)[:pos-1]
+		}
 	}
 	bitcom = &Bitcom{
 		ScriptPrefix: prefix,
@@ -41,6 +47,7 @@ func Decode(scr *script.Script) (bitcom *Bitcom) {
 			Pos: pos,
 		}
 		if op, err := scr.ReadOp(&pos); err != nil {
+			bitcom.AddWarning(-1, p.Pos, "malformed protocol prefix push: "+err.Error())
 			return
 		} else {
 			p.Protocol = string(op.Data)
@@ -51,6 +58,7 @@ func Decode(scr *script.Script) (bitcom *Bitcom) {
 			break
 		}
 		if pipePos < pos {
+			bitcom.AddWarning(len(bitcom.Protocols)-1, 0, "protocol prefix overlaps separator")
 			break
 		}
 		p.Script = (*scr)[pos:pipePos]
@@ -75,16 +83,30 @@ func (b *Bitcom) Lock() *script.Script {
 }
 
 func findReturn(scr *script.Script, from int) int {
+	pos, _ := findReturnOp(scr, from)
+	return pos
+}
+
+// findReturnOp returns the position of the first OP_RETURN from from, or -1, and
+// the opcode before it, nil if there is none. The search stops at a malformed
+// push, as the rest of the script is its data.
+func findReturnOp(scr *script.Script, from int) (int, *script.ScriptChunk) {
 	if scr != nil {
+		var prev *script.ScriptChunk
 		i := from
 		for i < len(*scr) {
 			startPos := i
-			if op, err := scr.ReadOp(&i); err == nil && op.Op == script.OpRETURN {
-				return startPos
+			op, err := scr.ReadOp(&i)
+			if err != nil {
+				break
 			}
+			if op.Op == script.OpRETURN {
+				return startPos, prev
+			}
+			prev = op
 		}
 	}
-	return -1
+	return -1, nil
 }
 
 func findPipe(scr *script.Script, from int) int {
@@ -92,7 +114,11 @@ func findPipe(scr *script.Script, from int) int {
 		i := from
 		for i < len(*scr) {
 			startPos := i
-			if op, err := scr.ReadOp(&i); err == nil && op.Op == script.OpDATA1 && op.Data[0] == '|' {
+			op, err := scr.ReadOp(&i)
+			if err != nil {
+				break
+			}
+			if op.Op == script.OpDATA1 && op.Data[0] == '|' {
 				return startPos
 			}
 		}
@@ -277,3 +277,54 @@ func TestFindPipe_EmptyScript(t *testing.T) {
 	pos = findPipe(emptyScript, 0)
 	require.Equal(t, -1, pos, "findPipe should return -1 for empty script")
 }
+
+// TestDecode_TruncatedPush verifies that the search for OP_RETURN and pipes stops
+// at a push running past the end of the script
+func TestDecode_TruncatedPush(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	truncated := script.NewFromBytes([]byte{script.OpPUSHDATA1})
+	require.Equal(t, -1, findReturn(truncated, 0))
+	require.Equal(t, -1, findPipe(truncated, 0))
+	require.Nil(t, Decode(truncated))
+
+	result := Decode(script.NewFromBytes([]byte{script.OpRETURN, script.OpPUSHDATA1}))
+	require.NotNil(t, result)
+	require.Empty(t, result.Protocols)
+	require.NotEmpty(t, result.Warnings, "The malformed push should be reported")
+}
+
+// TestDecode_ScriptPrefix verifies that an OP_FALSE opcode before the OP_RETURN is
+// left out of the prefix, while an executable prefix before a plain OP_RETURN is
+// kept whole, even when its last push ends in a zero byte
+func TestDecode_ScriptPrefix(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	lock, err := script.NewFromHex("76a914000000000000000000000000000000000000000088ac")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		prefix []byte
+		want   []byte
+	}{
+		{name: "data carrier", prefix: []byte{script.OpFALSE}, want: []byte{}},
+		{name: "executable lock", prefix: *lock, want: *lock},
+		{name: "executable lock and OP_FALSE", prefix: append(append([]byte{}, *lock...), script.OpFALSE), want: *lock},
+		{name: "push ending in a zero byte", prefix: []byte{script.OpDATA2, 0x01, 0x00}, want: []byte{script.OpDATA2, 0x01, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := script.NewFromBytes(append(append([]byte{}, tt.prefix...), script.OpRETURN))
+			_ = s.AppendPushData([]byte(MapPrefix))
+			_ = s.AppendPushData([]byte("SET"))
+
+			result := Decode(s)
+			require.NotNil(t, result)
+			require.Equal(t, tt.want, result.ScriptPrefix)
+			require.Len(t, result.Protocols, 1)
+		})
+	}
+}
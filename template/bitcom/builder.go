@@ -0,0 +1,141 @@
+package bitcom
+
+import (
+	"errors"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+var (
+	ErrNoProtocols  = errors.New("no protocols to sign")
+	ErrNoPrivateKey = errors.New("private key not supplied")
+	ErrNoOutpoint   = errors.New("outpoint not supplied")
+)
+
+// Encoder is implemented by protocols that can encode themselves as a Bitcom protocol segment
+type Encoder interface {
+	Encode() (*BitcomProtocol, error)
+}
+
+// signerFunc produces a signature protocol over the Bitcom built so far
+type signerFunc func(b *Bitcom) (*BitcomProtocol, error)
+
+// Builder composes Bitcom protocol segments into an OP_FALSE OP_RETURN locking script.
+// Segments are separated by "|" and any signatures are applied, in the order they
+// were requested, after all other segments have been added.
+//
+//	s, err := bitcom.NewBuilder().
+//		AddProtocol(bitcom.MapPrefix, []byte("SET"), []byte("app"), []byte("myapp")).
+//		SignAIP(key).
+//		Build()
+type Builder struct {
+	protocols []*BitcomProtocol
+	signers   []signerFunc
+	err       error
+}
+
+// NewBuilder creates an empty Bitcom builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add appends a protocol segment produced by an Encoder
+func (bb *Builder) Add(e Encoder) *Builder {
+	if bb.err != nil {
+		return bb
+	}
+	if p, err := e.Encode(); err != nil {
+		bb.err = err
+	} else {
+		bb.protocols = append(bb.protocols, p)
+	}
+	return bb
+}
+
+// AddProtocol appends a protocol segment made of the prefix followed by one push per field
+func (bb *Builder) AddProtocol(prefix string, fields ...[]byte) *Builder {
+	if bb.err != nil {
+		return bb
+	}
+	if p, err := newProtocol(prefix, fields...); err != nil {
+		bb.err = err
+	} else {
+		bb.protocols = append(bb.protocols, p)
+	}
+	return bb
+}
+
+// SignAIP signs every preceding field with an AIP signature using the BITCOIN_ECDSA algorithm
+func (bb *Builder) SignAIP(key *ec.PrivateKey) *Builder {
+	return bb.SignAIPFields(key, AIPAlgoBitcoinECDSA)
+}
+
+// SignAIPFields signs the preceding fields at fieldIndexes, or every field if none
+// are given, with an AIP signature using the given algorithm label
+func (bb *Builder) SignAIPFields(key *ec.PrivateKey, algorithm string, fieldIndexes ...int) *Builder {
+	bb.signers = append(bb.signers, func(b *Bitcom) (*BitcomProtocol, error) {
+		aip, err := SignAIP(b, key, algorithm, fieldIndexes...)
+		if err != nil {
+			return nil, err
+		}
+		return aip.Encode()
+	})
+	return bb
+}
+
+// SignSigma signs the script built so far with a SIGMA signature bound to the
+// outpoint spent by input vin of the transaction the output will be placed in
+func (bb *Builder) SignSigma(key *ec.PrivateKey, outpoint *transaction.Outpoint, vin int) *Builder {
+	bb.signers = append(bb.signers, func(b *Bitcom) (*BitcomProtocol, error) {
+		sigma, err := SignSigma(b, key, outpoint, vin)
+		if err != nil {
+			return nil, err
+		}
+		return sigma.Encode()
+	})
+	return bb
+}
+
+// Bitcom returns the composed Bitcom with all signatures applied
+func (bb *Builder) Bitcom() (*Bitcom, error) {
+	if bb.err != nil {
+		return nil, bb.err
+	}
+	b := &Bitcom{
+		ScriptPrefix: []byte{script.OpFALSE},
+		Protocols:    append([]*BitcomProtocol{}, bb.protocols...),
+	}
+	for _, sign := range bb.signers {
+		p, err := sign(b)
+		if err != nil {
+			return nil, err
+		}
+		b.Protocols = append(b.Protocols, p)
+	}
+	return b, nil
+}
+
+// Build returns the OP_FALSE OP_RETURN locking script with all signatures applied
+func (bb *Builder) Build() (*script.Script, error) {
+	b, err := bb.Bitcom()
+	if err != nil {
+		return nil, err
+	}
+	return b.Lock(), nil
+}
+
+// newProtocol creates a protocol segment with one push per field
+func newProtocol(prefix string, fields ...[]byte) (*BitcomProtocol, error) {
+	s := &script.Script{}
+	for _, field := range fields {
+		if err := s.AppendPushData(field); err != nil {
+			return nil, err
+		}
+	}
+	return &BitcomProtocol{
+		Protocol: prefix,
+		Script:   *s,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package bitcom
+
+import (
+	"testing"
+
+	gosigma "github.com/bitcoinschema/go-sigma"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuilder verifies that the builder composes protocols with pipe separators
+func TestBuilder(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	s, err := NewBuilder().
+		AddProtocol(BPrefix, []byte("Hello world"), []byte(MediaTypeTextPlain), []byte(EncodingUTF8)).
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		Build()
+	require.NoError(t, err)
+
+	expected := &script.Script{}
+	_ = expected.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = expected.AppendPushDataString(BPrefix)
+	_ = expected.AppendPushDataString("Hello world")
+	_ = expected.AppendPushDataString(string(MediaTypeTextPlain))
+	_ = expected.AppendPushDataString(string(EncodingUTF8))
+	_ = expected.AppendPushDataString("|")
+	_ = expected.AppendPushDataString(MapPrefix)
+	_ = expected.AppendPushDataString("SET")
+	_ = expected.AppendPushDataString("app")
+	_ = expected.AppendPushDataString("test")
+	require.Equal(t, expected.Bytes(), s.Bytes())
+
+	bc := Decode(s)
+	require.NotNil(t, bc)
+	require.Len(t, bc.Protocols, 2)
+}
+
+// TestBuilder_SignAIP verifies that AIP signatures produced by the builder validate
+func TestBuilder_SignAIP(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	require.NoError(t, err)
+
+	s, err := NewBuilder().
+		AddProtocol(BPrefix, []byte("Hello world"), []byte(MediaTypeTextPlain), []byte(EncodingUTF8)).
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		SignAIP(key).
+		Build()
+	require.NoError(t, err)
+
+	aips := DecodeAIP(Decode(s))
+	require.Len(t, aips, 1)
+	require.Equal(t, address.AddressString, aips[0].Address)
+	require.Equal(t, "BITCOIN_ECDSA", aips[0].Algorithm)
+	require.True(t, aips[0].Valid, "AIP signature should be valid")
+
+	_, err = NewBuilder().SignAIP(key).Build()
+	require.ErrorIs(t, err, ErrNoProtocols)
+
+	_, err = NewBuilder().AddProtocol(MapPrefix, []byte("SET")).SignAIP(nil).Build()
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+}
+
+// TestBuilder_SignSigma verifies that SIGMA signatures produced by the builder
+// are accepted by go-sigma
+func TestBuilder_SignSigma(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	txid, err := chainhash.NewHashFromHex("a7a2632627a7e19aef35c8110758b05c1cc14ffb9bc3df54092f5b81f9799d37")
+	require.NoError(t, err)
+	outpoint := &transaction.Outpoint{Txid: *txid, Index: 1}
+
+	s, err := NewBuilder().
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		SignSigma(key, outpoint, 0).
+		Build()
+	require.NoError(t, err)
+
+	tx := transaction.NewTransaction()
+	tx.AddInput(&transaction.TransactionInput{
+		SourceTXID:       txid,
+		SourceTxOutIndex: outpoint.Index,
+	})
+	tx.AddOutput(&transaction.TransactionOutput{
+		LockingScript: s,
+	})
+
+	goSigma := gosigma.NewSigma(*tx, 0, 0, 0)
+	goSigma.SetHashes()
+	require.True(t, goSigma.Verify(), "go-sigma should verify the signature")
+
+	_, err = NewBuilder().SignSigma(key, nil, 0).Build()
+	require.ErrorIs(t, err, ErrNoOutpoint)
+}
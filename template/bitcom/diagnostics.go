@@ -0,0 +1,99 @@
+package bitcom
+
+import (
+	"fmt"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// DecodeWarning describes a problem found while decoding a Bitcom script.
+// Decoders never write to stdout or a logger; problems are recorded on the
+// Bitcom being decoded and the affected protocol is skipped or left unverified.
+type DecodeWarning struct {
+	Protocol string `json:"proto,omitempty"`
+	Index    int    `json:"ii"`     // Index of the protocol within the Bitcom, -1 if not specific to one
+	Offset   int    `json:"offset"` // Byte offset of the problem within the locking script
+	Reason   string `json:"reason"`
+}
+
+// String formats the warning for logging
+func (w *DecodeWarning) String() string {
+	if w.Index < 0 {
+		return fmt.Sprintf("offset %d: %s", w.Offset, w.Reason)
+	}
+	return fmt.Sprintf("protocol %d (%s) offset %d: %s", w.Index, w.Protocol, w.Offset, w.Reason)
+}
+
+// AddWarning records a problem with the protocol at index idx. offset is relative
+// to the start of the protocol's Script, so decoders can report the position of
+// the field they failed on; it is converted to an offset within the locking script.
+// Decoders registered with Register should use it to explain why they return nil.
+// A warning identical to one already recorded is ignored, so decoding the same
+// Bitcom again does not repeat warnings.
+func (b *Bitcom) AddWarning(idx, offset int, reason string) {
+	w := &DecodeWarning{
+		Index:  idx,
+		Offset: offset,
+		Reason: reason,
+	}
+	if idx >= 0 && idx < len(b.Protocols) {
+		p := b.Protocols[idx]
+		w.Protocol = p.Protocol
+		w.Offset += p.dataOffset()
+	}
+	for _, existing := range b.Warnings {
+		if *existing == *w {
+			return
+		}
+	}
+	b.Warnings = append(b.Warnings, w)
+}
+
+// warnFunc records a problem at offset within a protocol's Script
+type warnFunc func(offset int, reason string)
+
+// warnAt returns a warnFunc that adds warnings for the protocol at index idx
+func (b *Bitcom) warnAt(idx int) warnFunc {
+	return func(offset int, reason string) {
+		b.AddWarning(idx, offset, reason)
+	}
+}
+
+// ignoreWarning discards the problems found by decoders called without a Bitcom
+func ignoreWarning(int, string) {}
+
+// readField reads the next field of scr, warning with the field's offset if it
+// is missing or malformed
+func readField(scr *script.Script, pos *int, field string, warn warnFunc) (*script.ScriptChunk, error) {
+	start := *pos
+	op, err := scr.ReadOp(pos)
+	switch {
+	case err == nil:
+	case start >= len(*scr):
+		warn(start, "missing "+field)
+	default:
+		warn(start, fmt.Sprintf("malformed %s: %v", field, err))
+	}
+	return op, err
+}
+
+// WarningsFor returns the warnings recorded for the protocol at index idx
+func (b *Bitcom) WarningsFor(idx int) []*DecodeWarning {
+	var warnings []*DecodeWarning
+	for _, w := range b.Warnings {
+		if w.Index == idx {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// dataOffset returns the offset within the locking script of the protocol's Script,
+// which follows the push of its prefix
+func (p *BitcomProtocol) dataOffset() int {
+	prefix := &script.Script{}
+	if err := prefix.AppendPushData([]byte(p.Protocol)); err != nil {
+		return p.Pos
+	}
+	return p.Pos + len(*prefix)
+}
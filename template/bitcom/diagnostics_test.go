@@ -0,0 +1,168 @@
+package bitcom
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeWarnings verifies that decoders record why a protocol could not be
+// decoded, with the offset of the problem within the locking script
+func TestDecodeWarnings(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	s, err := NewBuilder().
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		AddProtocol(SIGMAPrefix, []byte(AlgoBSM)).
+		AddProtocol(BPrefix, []byte("data only")).
+		Build()
+	require.NoError(t, err)
+
+	b := Decode(s)
+	require.NotNil(t, b)
+	results := b.DecodeProtocols()
+	require.Len(t, results, 3)
+
+	require.NotNil(t, results[0].Value)
+	require.Empty(t, results[0].Warnings, "Valid protocols should have no warnings")
+
+	// The SIGMA segment ends after its algorithm
+	require.Nil(t, results[1].Value)
+	require.Equal(t, []*DecodeWarning{{
+		Protocol: SIGMAPrefix,
+		Index:    1,
+		Offset:   len(b.Protocols[1].Script) + b.Protocols[1].dataOffset(),
+		Reason:   "missing SIGMA signer address",
+	}}, results[1].Warnings)
+
+	// The B segment ends after its data
+	require.Nil(t, results[2].Value)
+	require.Equal(t, []*DecodeWarning{{
+		Protocol: BPrefix,
+		Index:    2,
+		Offset:   len(b.Protocols[2].Script) + b.Protocols[2].dataOffset(),
+		Reason:   "missing B media type",
+	}}, results[2].Warnings)
+
+	require.Len(t, b.Warnings, 2)
+	require.Equal(t, b.Warnings[1:], b.WarningsFor(2))
+
+	// Decoding again does not repeat warnings
+	b.DecodeProtocols()
+	DecodeSIGMA(b)
+	require.Len(t, b.Warnings, 2)
+}
+
+// TestDecodeWarnings_Decoders verifies that each built in decoder records why it
+// failed, with the offset of the field it failed on
+func TestDecodeWarnings_Decoders(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	txid := make([]byte, 32)
+	tests := []struct {
+		name   string
+		prefix string
+		fields [][]byte
+		field  int // Index of the field the problem is at, len(fields) if missing
+		reason string
+	}{
+		{"B without encoding", BPrefix, [][]byte{[]byte("data"), []byte("text/plain")}, 2, "missing B encoding"},
+		{"MAP too short", MapPrefix, [][]byte{[]byte("SET")}, 0, "MAP data is 4 bytes, shorter than the minimum of 6"},
+		{"BCAT without flag", BCATPrefix, [][]byte{[]byte("info"), []byte("text/plain"), []byte("utf-8"), []byte("a.txt")}, 4, "missing BCAT flag"},
+		{"BCAT without parts", BCATPrefix, [][]byte{[]byte("info"), []byte("text/plain"), []byte("utf-8"), []byte("a.txt"), []byte(" ")}, 5, "missing BCAT part txid"},
+		{"BCAT short txid", BCATPrefix, [][]byte{[]byte("info"), []byte("text/plain"), []byte("utf-8"), []byte("a.txt"), []byte(" "), txid, []byte("short")}, 6, "BCAT part txid is 5 bytes, not 32"},
+		{"BCAT part without data", BCATPartPrefix, nil, 0, "missing BCAT part data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewBuilder().
+				AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+				AddProtocol(tt.prefix, tt.fields...).
+				Build()
+			require.NoError(t, err)
+			b := Decode(s)
+			require.NotNil(t, b)
+
+			results := b.DecodeProtocols()
+			require.Len(t, results, 2)
+			require.Nil(t, results[1].Value)
+
+			fields := &script.Script{}
+			require.NoError(t, fields.AppendPushDataArray(tt.fields[:tt.field]))
+			require.Equal(t, []*DecodeWarning{{
+				Protocol: tt.prefix,
+				Index:    1,
+				Offset:   len(*fields) + b.Protocols[1].dataOffset(),
+				Reason:   tt.reason,
+			}}, results[1].Warnings)
+		})
+	}
+
+	// Decoders that do not explain a failure get a generic warning
+	const prefix = "1TestDecoderReturnsNil"
+	Register(prefix, func(*Bitcom, int) any { return nil })
+	defer Unregister(prefix)
+	s, err := NewBuilder().AddProtocol(prefix, []byte("anything")).Build()
+	require.NoError(t, err)
+	b := Decode(s)
+	results := b.DecodeProtocols()
+	require.Len(t, results, 1)
+	require.Equal(t, []*DecodeWarning{{
+		Protocol: prefix,
+		Index:    0,
+		Offset:   b.Protocols[0].dataOffset(),
+		Reason:   "protocol data could not be decoded",
+	}}, results[0].Warnings)
+}
+
+// TestDecodeWarnings_Silent verifies that decoding problems are never written to stdout
+func TestDecodeWarnings_Silent(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	sigma := &script.Script{}
+	_ = sigma.AppendPushData([]byte(AlgoBSM))
+	_ = sigma.AppendPushData([]byte("1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz"))
+	_ = sigma.AppendPushData([]byte("not a signature"))
+	_ = sigma.AppendPushData([]byte("message"))
+	b := &Bitcom{Protocols: []*BitcomProtocol{
+		{Protocol: SIGMAPrefix, Script: *sigma},
+		{Protocol: AIPPrefix, Script: []byte{}},
+	}}
+
+	stdout := captureStdout(t, func() {
+		sigs := DecodeSIGMA(b)
+		require.Len(t, sigs, 1)
+		require.False(t, sigs[0].Valid)
+		b.DecodeProtocols()
+		DecodeFromTransaction(sigmaTestTx(t, b.Lock()))
+	})
+	require.Empty(t, stdout, "Decoding should not write to stdout")
+
+	require.Len(t, b.WarningsFor(0), 1)
+	require.Contains(t, b.WarningsFor(0)[0].Reason, "SIGMA message signature not verified")
+	require.Len(t, b.WarningsFor(1), 1)
+	require.Equal(t, "AIP requires an algorithm, address and signature", b.WarningsFor(1)[0].Reason)
+}
+
+// captureStdout returns everything written to stdout while fn runs
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
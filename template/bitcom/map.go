@@ -2,6 +2,9 @@ package bitcom
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/bsv-blockchain/go-sdk/script"
@@ -20,16 +23,56 @@ var (
 	MapCmdSelect MapCmd = "SELECT"
 )
 
+var (
+	ErrUnknownMapCmd   = errors.New("unknown MAP command")
+	ErrMissingMapKey   = errors.New("MAP command requires a key")
+	ErrMissingMapTxID  = errors.New("MAP SELECT requires a txid")
+	ErrNestedMapSelect = errors.New("MAP SELECT cannot contain another SELECT")
+)
+
+// MapPair is a single SET key/value pair
+type MapPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Map represents a single MAP command.
+//
+//	SET <key> <value> [<key> <value> ...]
+//	ADD <key> <value> [<value> ...]
+//	DEL <key> [<key> ...]
+//	SELECT <txid> <command> [<command> ...]
+//
+// For SET, Data holds the current value of each key and Pairs holds every
+// key/value pair in script order, including repeated keys. Data is the source of
+// truth: editing Data on a decoded Map changes what Get returns and what Encode
+// writes, and Pairs only decides the order keys are written in.
 type Map struct {
-	Cmd  MapCmd            `json:"cmd"`
-	Data map[string]string `json:"data"`
-	Adds []string          `json:"adds,omitempty"`
+	Cmd      MapCmd            `json:"cmd"`
+	Data     map[string]string `json:"data"`
+	Pairs    []MapPair         `json:"pairs,omitempty"`    // SET: pairs in script order
+	Key      string            `json:"key,omitempty"`      // ADD: key the values are added to
+	Adds     []string          `json:"adds,omitempty"`     // ADD: values added to Key
+	Deletes  []string          `json:"deletes,omitempty"`  // DEL: keys to remove
+	TxID     string            `json:"txid,omitempty"`     // SELECT: transaction the commands apply to
+	Commands []*Map            `json:"commands,omitempty"` // SELECT: commands applied to TxID
 }
 
 // DecodeMap decodes the map data from the transaction script
 func DecodeMap(data any) *Map {
-	scr := ToScript(data)
+	return decodeMap(ToScript(data), ignoreWarning)
+}
+
+// decodeMapAt decodes the MAP protocol at index idx of the Bitcom, recording why
+// it could not be decoded as a warning
+func decodeMapAt(b *Bitcom, idx int) *Map {
+	return decodeMap(ToScript(b.Protocols[idx].Script), b.warnAt(idx))
+}
+
+// decodeMap decodes a MAP command from scr, reporting problems to warn
+func decodeMap(scr *script.Script, warn warnFunc) *Map {
 	if scr == nil || len(*scr) == 0 {
+		warn(0, "missing MAP command")
 		return nil
 	}
 
@@ -39,47 +82,238 @@ func DecodeMap(data any) *Map {
 
 	// If length is < minimum, return nil
 	if len(*scr) < 6 {
+		warn(0, fmt.Sprintf("MAP data is %d bytes, shorter than the minimum of 6", len(*scr)))
 		return nil
 	}
 
 	// Read command
-	if op, err = scr.ReadOp(&pos); err != nil {
+	if op, err = readField(scr, &pos, "MAP command", warn); err != nil {
 		return nil
 	}
 	cmd := MapCmd(op.Data)
+	if !isMapCmd(string(cmd)) {
+		warn(0, fmt.Sprintf("unknown MAP command %q", cmd))
+	}
 
-	// Create map
+	// Read remaining fields
+	var args []string
+	for pos < len(*scr) {
+		if op, err = readField(scr, &pos, "MAP field", warn); err != nil {
+			break
+		}
+		args = append(args, cleanMapValue(op.Data))
+	}
+
+	// Top level commands consume every remaining field
+	m, _ := parseMapCmd(cmd, args, false)
+	return m
+}
+
+// parseMapCmd builds a Map for cmd from args. When nested is true the command ends
+// at the next MAP command keyword and the unconsumed args are returned.
+func parseMapCmd(cmd MapCmd, args []string, nested bool) (*Map, []string) {
 	m := &Map{
 		Cmd:  cmd,
 		Data: make(map[string]string),
 	}
 
-	// Handle SET command
-	if cmd == MapCmdSet {
-		for {
-			// Save position to revert if needed
-			keyPos := pos
+	switch cmd {
+	case MapCmdSet:
+		for len(args) >= 2 && !(nested && isMapCmd(args[0])) {
+			m.Set(args[0], args[1])
+			args = args[2:]
+		}
+		// A key without a value is dropped
+		if len(args) == 1 && !(nested && isMapCmd(args[0])) {
+			args = args[1:]
+		}
+	case MapCmdAdd:
+		if len(args) > 0 {
+			m.Key = args[0]
+			args = args[1:]
+		}
+		for len(args) > 0 && !(nested && isMapCmd(args[0])) {
+			m.Adds = append(m.Adds, args[0])
+			args = args[1:]
+		}
+	case MapCmdDel:
+		for len(args) > 0 && !(nested && isMapCmd(args[0])) {
+			m.Deletes = append(m.Deletes, args[0])
+			args = args[1:]
+		}
+	case MapCmdSelect:
+		if nested {
+			return m, args
+		}
+		if len(args) > 0 {
+			m.TxID = args[0]
+			args = args[1:]
+		}
+		for len(args) > 0 && isMapCmd(args[0]) && MapCmd(args[0]) != MapCmdSelect {
+			var sub *Map
+			sub, args = parseMapCmd(MapCmd(args[0]), args[1:], true)
+			m.Commands = append(m.Commands, sub)
+		}
+	}
+
+	return m, args
+}
 
-			// Try to read key
-			if op, err = scr.ReadOp(&pos); err != nil {
-				break
-			}
-			opKey := strings.ReplaceAll(string(bytes.ReplaceAll(op.Data, []byte{0}, []byte{' '})), "\\u0000", " ")
+// isMapCmd reports whether s is a MAP command keyword
+func isMapCmd(s string) bool {
+	switch MapCmd(s) {
+	case MapCmdSet, MapCmdAdd, MapCmdDel, MapCmdSelect:
+		return true
+	}
+	return false
+}
 
-			// Try to read value
-			if op, err = scr.ReadOp(&pos); err != nil {
-				// Couldn't read value, revert to position before key and break
-				pos = keyPos
-				break
-			}
+// cleanMapValue replaces null bytes with spaces rather than dropping the field
+func cleanMapValue(data []byte) string {
+	return strings.ReplaceAll(string(bytes.ReplaceAll(data, []byte{0}, []byte{' '})), "\\u0000", " ")
+}
 
-			// Clean up value, replacing invalid UTF-8 sequences with spaces
-			// rather than skipping the entire key-value pair
-			cleanValue := strings.ReplaceAll(string(bytes.ReplaceAll(op.Data, []byte{0}, []byte{' '})), "\\u0000", " ")
+// Set appends a key/value pair, keeping Pairs and Data in sync
+func (m *Map) Set(key, value string) {
+	if m.Data == nil {
+		m.Data = make(map[string]string)
+	}
+	m.Pairs = append(m.Pairs, MapPair{Key: key, Value: value})
+	m.Data[key] = value
+}
 
-			m.Data[opKey] = cleanValue
+// Get returns the current value of key. Data is authoritative when present, so
+// edits made to Data are seen; otherwise the last pair set for key is used.
+func (m *Map) Get(key string) (string, bool) {
+	if m.Data != nil {
+		value, ok := m.Data[key]
+		return value, ok
+	}
+	for i := len(m.Pairs) - 1; i >= 0; i-- {
+		if m.Pairs[i].Key == key {
+			return m.Pairs[i].Value, true
 		}
 	}
+	return "", false
+}
 
-	return m
+// Values returns every value set for key, in script order
+func (m *Map) Values(key string) []string {
+	var values []string
+	for _, pair := range m.OrderedPairs() {
+		if pair.Key == key {
+			values = append(values, pair.Value)
+		}
+	}
+	return values
+}
+
+// OrderedPairs returns the SET pairs of the map in the order they are encoded.
+// Data holds the current value of each key and Pairs their script order,
+// including repeated keys. Pairs is used as is while it agrees with Data. Once
+// Data has been edited, each key in Data is written once, in the order it first
+// appears in Pairs, followed by new keys in sorted order.
+func (m *Map) OrderedPairs() []MapPair {
+	if m.Data == nil || m.pairsMatchData() {
+		return m.Pairs
+	}
+	pairs := make([]MapPair, 0, len(m.Data))
+	seen := make(map[string]bool, len(m.Data))
+	for _, pair := range m.Pairs {
+		if value, ok := m.Data[pair.Key]; ok && !seen[pair.Key] {
+			seen[pair.Key] = true
+			pairs = append(pairs, MapPair{Key: pair.Key, Value: value})
+		}
+	}
+	keys := make([]string, 0, len(m.Data)-len(seen))
+	for key := range m.Data {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		pairs = append(pairs, MapPair{Key: key, Value: m.Data[key]})
+	}
+	return pairs
+}
+
+// pairsMatchData reports whether the last value of every key in Pairs is its
+// value in Data, and Data has no other keys
+func (m *Map) pairsMatchData() bool {
+	last := make(map[string]string, len(m.Data))
+	for _, pair := range m.Pairs {
+		last[pair.Key] = pair.Value
+	}
+	if len(last) != len(m.Data) {
+		return false
+	}
+	for key, value := range last {
+		if current, ok := m.Data[key]; !ok || current != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode encodes the MAP command as a Bitcom protocol segment.
+// SET pairs are written in the order of Pairs while it agrees with Data; keys only
+// in Data are written in sorted order, so identical metadata always produces
+// identical scripts.
+func (m *Map) Encode() (*BitcomProtocol, error) {
+	s := &script.Script{}
+	if err := m.appendTo(s, false); err != nil {
+		return nil, err
+	}
+	return &BitcomProtocol{
+		Protocol: MapPrefix,
+		Script:   *s,
+	}, nil
+}
+
+// appendTo writes the command and its fields to s
+func (m *Map) appendTo(s *script.Script, nested bool) error {
+	fields := []string{string(m.Cmd)}
+	switch m.Cmd {
+	case MapCmdSet:
+		for _, pair := range m.OrderedPairs() {
+			fields = append(fields, pair.Key, pair.Value)
+		}
+	case MapCmdAdd:
+		if m.Key == "" {
+			return ErrMissingMapKey
+		}
+		fields = append(fields, m.Key)
+		fields = append(fields, m.Adds...)
+	case MapCmdDel:
+		if len(m.Deletes) == 0 {
+			return ErrMissingMapKey
+		}
+		fields = append(fields, m.Deletes...)
+	case MapCmdSelect:
+		if nested {
+			return ErrNestedMapSelect
+		}
+		if m.TxID == "" {
+			return ErrMissingMapTxID
+		}
+		fields = append(fields, m.TxID)
+	default:
+		return ErrUnknownMapCmd
+	}
+
+	for _, field := range fields {
+		if err := s.AppendPushDataString(field); err != nil {
+			return err
+		}
+	}
+
+	if m.Cmd == MapCmdSelect {
+		for _, sub := range m.Commands {
+			if err := sub.appendTo(s, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,142 @@
+package bitcom
+
+import (
+	"cmp"
+	"slices"
+)
+
+// MapUpdate is a decoded MAP command and the position of the transaction that carried it
+type MapUpdate struct {
+	Map    *Map   `json:"map"`
+	TxID   string `json:"txid"`
+	Height uint32 `json:"height"` // Block height, 0 if unconfirmed
+	Idx    uint64 `json:"idx"`    // Index of the transaction within the block
+	Vout   uint32 `json:"vout"`   // Output carrying the MAP data
+}
+
+// MapOrder compares two updates, returning a negative number when a was applied before b
+type MapOrder func(a, b *MapUpdate) int
+
+// MapOrderByBlock orders updates by block height, then index within the block, then output.
+// Unconfirmed updates are applied after all mined updates. Remaining ties are broken by
+// txid so every consumer of the same updates arrives at the same state.
+func MapOrderByBlock(a, b *MapUpdate) int {
+	if c := compareBlockPosition(a.Height, a.Idx, b.Height, b.Idx); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.TxID, b.TxID); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Vout, b.Vout)
+}
+
+// compareBlockPosition orders transactions by block height, then index within the block.
+// Unconfirmed transactions (height 0) come after all mined transactions.
+func compareBlockPosition(aHeight uint32, aIdx uint64, bHeight uint32, bIdx uint64) int {
+	if aPending, bPending := aHeight == 0, bHeight == 0; aPending != bPending {
+		if aPending {
+			return 1
+		}
+		return -1
+	}
+	if c := cmp.Compare(aHeight, bHeight); c != 0 {
+		return c
+	}
+	return cmp.Compare(aIdx, bIdx)
+}
+
+// MapState is the result of applying a sequence of MAP commands to an entity
+type MapState struct {
+	Values     map[string][]string  `json:"values"`
+	Selections map[string]*MapState `json:"selections,omitempty"` // State of the transactions targeted by SELECT, keyed by txid
+}
+
+// NewMapState creates an empty MAP state
+func NewMapState() *MapState {
+	return &MapState{
+		Values:     make(map[string][]string),
+		Selections: make(map[string]*MapState),
+	}
+}
+
+// ReduceMap sorts updates with order (MapOrderByBlock if nil) and applies them
+// to an empty state. Nil updates are skipped, so order is never called with nil.
+// The updates slice is not modified.
+func ReduceMap(updates []*MapUpdate, order MapOrder) *MapState {
+	if order == nil {
+		order = MapOrderByBlock
+	}
+	sorted := slices.DeleteFunc(slices.Clone(updates), func(u *MapUpdate) bool { return u == nil })
+	slices.SortStableFunc(sorted, order)
+
+	state := NewMapState()
+	for _, u := range sorted {
+		state.Apply(u.Map)
+	}
+	return state
+}
+
+// Apply applies a single MAP command to the state.
+// SET overwrites a key, ADD appends values to a key and DEL removes keys.
+// The commands nested in a SELECT target another transaction's metadata, so they
+// are applied in order to the selection for its TxID rather than to s.
+func (s *MapState) Apply(m *Map) {
+	if m == nil {
+		return
+	}
+	switch m.Cmd {
+	case MapCmdSet:
+		for _, pair := range m.OrderedPairs() {
+			s.Values[pair.Key] = []string{pair.Value}
+		}
+	case MapCmdAdd:
+		if m.Key != "" {
+			s.Values[m.Key] = append(s.Values[m.Key], m.Adds...)
+		}
+	case MapCmdDel:
+		for _, key := range m.Deletes {
+			delete(s.Values, key)
+		}
+	case MapCmdSelect:
+		if m.TxID == "" {
+			return
+		}
+		selection, ok := s.Selections[m.TxID]
+		if !ok {
+			selection = &MapState{Values: make(map[string][]string)}
+			if s.Selections == nil {
+				s.Selections = make(map[string]*MapState)
+			}
+			s.Selections[m.TxID] = selection
+		}
+		for _, sub := range m.Commands {
+			if sub != nil && sub.Cmd != MapCmdSelect {
+				selection.Apply(sub)
+			}
+		}
+	}
+}
+
+// Selection returns the state built by the SELECT commands targeting txid
+func (s *MapState) Selection(txid string) (*MapState, bool) {
+	selection, ok := s.Selections[txid]
+	return selection, ok
+}
+
+// Get returns the most recent value for key
+func (s *MapState) Get(key string) (string, bool) {
+	values := s.Values[key]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// Data returns the most recent value for every key
+func (s *MapState) Data() map[string]string {
+	data := make(map[string]string, len(s.Values))
+	for key := range s.Values {
+		data[key], _ = s.Get(key)
+	}
+	return data
+}
@@ -0,0 +1,112 @@
+package bitcom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReduceMap verifies that SET, ADD, DEL and SELECT are applied in block order
+func TestReduceMap(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	set := &Map{Cmd: MapCmdSet}
+	set.Set("name", "first")
+	set.Set("bio", "hello")
+
+	rename := &Map{Cmd: MapCmdSet}
+	rename.Set("name", "second")
+
+	updates := []*MapUpdate{
+		// Deliberately out of order
+		{TxID: "c", Height: 0, Map: &Map{Cmd: MapCmdDel, Deletes: []string{"bio"}}},
+		{TxID: "b", Height: 101, Idx: 5, Map: &Map{Cmd: MapCmdAdd, Key: "tags", Adds: []string{"one", "two"}}},
+		{TxID: "a", Height: 100, Idx: 3, Map: set},
+		{TxID: "d", Height: 101, Idx: 7, Map: &Map{
+			Cmd:      MapCmdSelect,
+			TxID:     "a",
+			Commands: []*Map{rename, {Cmd: MapCmdAdd, Key: "tags", Adds: []string{"three"}}},
+		}},
+	}
+
+	state := ReduceMap(updates, nil)
+	require.Equal(t, map[string][]string{
+		"name": {"first"},
+		"tags": {"one", "two"},
+	}, state.Values)
+
+	name, ok := state.Get("name")
+	require.True(t, ok)
+	require.Equal(t, "first", name)
+	require.Equal(t, map[string]string{"name": "first", "tags": "two"}, state.Data())
+
+	// The SELECT is applied to the state of the selected transaction only
+	selection, ok := state.Selection("a")
+	require.True(t, ok)
+	require.Equal(t, map[string][]string{
+		"name": {"second"},
+		"tags": {"three"},
+	}, selection.Values)
+
+	// The input order is not modified
+	require.Equal(t, "c", updates[0].TxID)
+
+	// The same updates in any order produce the same state
+	reversed := []*MapUpdate{updates[3], updates[2], updates[1], updates[0]}
+	require.Equal(t, state, ReduceMap(reversed, nil))
+}
+
+// TestReduceMap_CustomOrder verifies that a custom ordering key can be supplied
+func TestReduceMap_CustomOrder(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	first := &Map{Cmd: MapCmdSet}
+	first.Set("status", "draft")
+	second := &Map{Cmd: MapCmdSet}
+	second.Set("status", "published")
+
+	updates := []*MapUpdate{
+		{TxID: "x", Idx: 2, Map: second},
+		{TxID: "y", Idx: 1, Map: first},
+	}
+
+	// Order by a wallet's local sequence number only
+	byIdx := func(a, b *MapUpdate) int {
+		return int(a.Idx) - int(b.Idx)
+	}
+	status, _ := ReduceMap(updates, byIdx).Get("status")
+	require.Equal(t, "published", status)
+}
+
+// TestReduceMap_NilUpdate verifies that nil updates are skipped rather than sorted
+func TestReduceMap_NilUpdate(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	set := &Map{Cmd: MapCmdSet}
+	set.Set("name", "kept")
+	updates := []*MapUpdate{nil, {TxID: "a", Height: 100, Map: set}, nil}
+
+	require.NotPanics(t, func() {
+		name, _ := ReduceMap(updates, nil).Get("name")
+		require.Equal(t, "kept", name)
+	})
+	require.Nil(t, updates[0], "The updates slice is not modified")
+}
+
+// TestMapOrderByBlock verifies the default ordering rules
+func TestMapOrderByBlock(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	mined := &MapUpdate{Height: 800000}
+	pending := &MapUpdate{Height: 0}
+	require.Negative(t, MapOrderByBlock(mined, pending), "Mined updates come before unconfirmed ones")
+	require.Positive(t, MapOrderByBlock(pending, mined))
+
+	require.Negative(t, MapOrderByBlock(&MapUpdate{Height: 1, Idx: 1}, &MapUpdate{Height: 1, Idx: 2}))
+	require.Negative(t, MapOrderByBlock(&MapUpdate{Height: 1, TxID: "a"}, &MapUpdate{Height: 1, TxID: "b"}))
+	require.Zero(t, MapOrderByBlock(&MapUpdate{Height: 1, TxID: "a"}, &MapUpdate{Height: 1, TxID: "a"}))
+}
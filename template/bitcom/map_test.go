@@ -262,3 +262,232 @@ func TestToScript(t *testing.T) {
 	require.NotNil(t, mapFromScript, "DecodeMap should work with script")
 	require.NotNil(t, mapFromBytes, "DecodeMap should work with bytes")
 }
+
+// TestDecodeMap_Commands verifies decoding of the ADD, DEL and SELECT commands
+func TestDecodeMap_Commands(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	t.Run("ADD command", func(t *testing.T) {
+		resetTestState()
+
+		s := &script.Script{}
+		_ = s.AppendPushDataString(string(MapCmdAdd))
+		_ = s.AppendPushDataString("tags")
+		_ = s.AppendPushDataString("test")
+		_ = s.AppendPushDataString("bsv")
+
+		result := DecodeMap(s)
+		require.NotNil(t, result)
+		require.Equal(t, MapCmdAdd, result.Cmd)
+		require.Equal(t, "tags", result.Key)
+		require.Equal(t, []string{"test", "bsv"}, result.Adds)
+	})
+
+	t.Run("DEL command", func(t *testing.T) {
+		resetTestState()
+
+		s := &script.Script{}
+		_ = s.AppendPushDataString(string(MapCmdDel))
+		_ = s.AppendPushDataString("name")
+		_ = s.AppendPushDataString("bio")
+
+		result := DecodeMap(s)
+		require.NotNil(t, result)
+		require.Equal(t, MapCmdDel, result.Cmd)
+		require.Equal(t, []string{"name", "bio"}, result.Deletes)
+	})
+
+	t.Run("SELECT command with nested commands", func(t *testing.T) {
+		resetTestState()
+
+		txid := "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+		s := &script.Script{}
+		_ = s.AppendPushDataString(string(MapCmdSelect))
+		_ = s.AppendPushDataString(txid)
+		_ = s.AppendPushDataString(string(MapCmdSet))
+		_ = s.AppendPushDataString("name")
+		_ = s.AppendPushDataString("SET")
+		_ = s.AppendPushDataString(string(MapCmdAdd))
+		_ = s.AppendPushDataString("tags")
+		_ = s.AppendPushDataString("one")
+		_ = s.AppendPushDataString("two")
+		_ = s.AppendPushDataString(string(MapCmdDel))
+		_ = s.AppendPushDataString("bio")
+
+		result := DecodeMap(s)
+		require.NotNil(t, result)
+		require.Equal(t, MapCmdSelect, result.Cmd)
+		require.Equal(t, txid, result.TxID)
+		require.Len(t, result.Commands, 3)
+
+		require.Equal(t, MapCmdSet, result.Commands[0].Cmd)
+		require.Equal(t, "SET", result.Commands[0].Data["name"], "Values may equal command keywords")
+		require.Equal(t, MapCmdAdd, result.Commands[1].Cmd)
+		require.Equal(t, "tags", result.Commands[1].Key)
+		require.Equal(t, []string{"one", "two"}, result.Commands[1].Adds)
+		require.Equal(t, MapCmdDel, result.Commands[2].Cmd)
+		require.Equal(t, []string{"bio"}, result.Commands[2].Deletes)
+	})
+}
+
+// TestMapEncode verifies that every MAP command round-trips through Encode and DecodeMap
+func TestMapEncode(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	tests := []struct {
+		name string
+		m    *Map
+	}{
+		{
+			name: "SET",
+			m: &Map{
+				Cmd:   MapCmdSet,
+				Data:  map[string]string{"app": "bsocial", "type": "post"},
+				Pairs: []MapPair{{Key: "app", Value: "bsocial"}, {Key: "type", Value: "post"}},
+			},
+		},
+		{
+			name: "ADD",
+			m:    &Map{Cmd: MapCmdAdd, Data: map[string]string{}, Key: "tags", Adds: []string{"test", "bsv"}},
+		},
+		{
+			name: "DEL",
+			m:    &Map{Cmd: MapCmdDel, Data: map[string]string{}, Deletes: []string{"name"}},
+		},
+		{
+			name: "SELECT",
+			m: &Map{
+				Cmd:  MapCmdSelect,
+				Data: map[string]string{},
+				TxID: "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+				Commands: []*Map{
+					{Cmd: MapCmdSet, Data: map[string]string{"name": "satoshi"}, Pairs: []MapPair{{Key: "name", Value: "satoshi"}}},
+					{Cmd: MapCmdAdd, Data: map[string]string{}, Key: "tags", Adds: []string{"one"}},
+					{Cmd: MapCmdDel, Data: map[string]string{}, Deletes: []string{"bio"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTestState()
+
+			proto, err := tt.m.Encode()
+			require.NoError(t, err)
+			require.Equal(t, MapPrefix, proto.Protocol)
+
+			decoded := DecodeMap(proto.Script)
+			require.Equal(t, tt.m, decoded)
+
+			again, err := decoded.Encode()
+			require.NoError(t, err)
+			require.Equal(t, proto.Script, again.Script, "Encoding should be lossless")
+		})
+	}
+
+	t.Run("invalid commands", func(t *testing.T) {
+		resetTestState()
+
+		_, err := (&Map{Cmd: "UPDATE"}).Encode()
+		require.ErrorIs(t, err, ErrUnknownMapCmd)
+
+		_, err = (&Map{Cmd: MapCmdAdd, Adds: []string{"x"}}).Encode()
+		require.ErrorIs(t, err, ErrMissingMapKey)
+
+		_, err = (&Map{Cmd: MapCmdSelect}).Encode()
+		require.ErrorIs(t, err, ErrMissingMapTxID)
+
+		_, err = (&Map{Cmd: MapCmdSelect, TxID: "abc", Commands: []*Map{{Cmd: MapCmdSelect, TxID: "def"}}}).Encode()
+		require.ErrorIs(t, err, ErrNestedMapSelect)
+	})
+}
+
+// TestMapPairs verifies that SET pairs keep their order and repeated keys
+func TestMapPairs(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	s := &script.Script{}
+	_ = s.AppendPushDataString(string(MapCmdSet))
+	_ = s.AppendPushDataString("type")
+	_ = s.AppendPushDataString("post")
+	_ = s.AppendPushDataString("app")
+	_ = s.AppendPushDataString("first")
+	_ = s.AppendPushDataString("app")
+	_ = s.AppendPushDataString("second")
+
+	result := DecodeMap(s)
+	require.NotNil(t, result)
+	require.Equal(t, []MapPair{
+		{Key: "type", Value: "post"},
+		{Key: "app", Value: "first"},
+		{Key: "app", Value: "second"},
+	}, result.Pairs)
+	require.Equal(t, "second", result.Data["app"], "Data should hold the last value")
+	require.Equal(t, []string{"first", "second"}, result.Values("app"))
+
+	value, ok := result.Get("app")
+	require.True(t, ok)
+	require.Equal(t, "second", value)
+
+	// Encoding preserves the original order and duplicates
+	proto, err := result.Encode()
+	require.NoError(t, err)
+	require.Equal(t, s.Bytes(), proto.Script)
+
+	// Without Pairs, Data is encoded in sorted key order
+	for range 10 {
+		m := &Map{Cmd: MapCmdSet, Data: map[string]string{"type": "post", "app": "test", "name": "x"}}
+		proto, err := m.Encode()
+		require.NoError(t, err)
+		decoded := DecodeMap(proto.Script)
+		require.Equal(t, []MapPair{
+			{Key: "app", Value: "test"},
+			{Key: "name", Value: "x"},
+			{Key: "type", Value: "post"},
+		}, decoded.Pairs)
+	}
+}
+
+// TestMapDataEdits verifies that edits to Data on a decoded map are encoded
+func TestMapDataEdits(t *testing.T) {
+	// Reset test state
+	resetTestState()
+
+	s := &script.Script{}
+	for _, field := range []string{string(MapCmdSet), "type", "post", "app", "first", "app", "second", "name", "x"} {
+		_ = s.AppendPushDataString(field)
+	}
+	result := DecodeMap(s)
+	require.NotNil(t, result)
+
+	result.Data["app"] = "edited"
+	delete(result.Data, "name")
+	result.Data["context"] = "tx"
+
+	value, ok := result.Get("app")
+	require.True(t, ok)
+	require.Equal(t, "edited", value)
+	_, ok = result.Get("name")
+	require.False(t, ok, "Deleted keys are gone")
+
+	// Edited keys keep their first position, and new keys follow in sorted order
+	proto, err := result.Encode()
+	require.NoError(t, err)
+	decoded := DecodeMap(proto.Script)
+	require.Equal(t, []MapPair{
+		{Key: "type", Value: "post"},
+		{Key: "app", Value: "edited"},
+		{Key: "context", Value: "tx"},
+	}, decoded.Pairs)
+
+	// A map built from Pairs alone reads from its pairs
+	m := &Map{Cmd: MapCmdSet, Pairs: []MapPair{{Key: "app", Value: "a"}, {Key: "app", Value: "b"}}}
+	value, ok = m.Get("app")
+	require.True(t, ok)
+	require.Equal(t, "b", value)
+	require.Equal(t, []string{"a", "b"}, m.Values("app"))
+}
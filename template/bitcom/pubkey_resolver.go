@@ -0,0 +1,51 @@
+package bitcom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+var ErrPubKeysNotFound = errors.New("no public keys found for handle")
+
+// PubKeyResolver resolves an identity handle, such as a paymail, to the public keys
+// it may sign with
+type PubKeyResolver interface {
+	ResolvePubKeys(ctx context.Context, handle string) ([]*ec.PublicKey, error)
+}
+
+// StaticPubKeyResolver is an in-memory PubKeyResolver for offline verification.
+// Handles are case-insensitive. It is safe for concurrent use.
+type StaticPubKeyResolver struct {
+	mu   sync.RWMutex
+	keys map[string][]*ec.PublicKey
+}
+
+// NewStaticPubKeyResolver creates an empty in-memory PubKeyResolver
+func NewStaticPubKeyResolver() *StaticPubKeyResolver {
+	return &StaticPubKeyResolver{
+		keys: make(map[string][]*ec.PublicKey),
+	}
+}
+
+// Add associates public keys with a handle
+func (r *StaticPubKeyResolver) Add(handle string, keys ...*ec.PublicKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	handle = strings.ToLower(handle)
+	r.keys[handle] = append(r.keys[handle], keys...)
+}
+
+// ResolvePubKeys returns the public keys for handle, or ErrPubKeysNotFound
+func (r *StaticPubKeyResolver) ResolvePubKeys(_ context.Context, handle string) ([]*ec.PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := r.keys[strings.ToLower(handle)]
+	if len(keys) == 0 {
+		return nil, ErrPubKeysNotFound
+	}
+	return append([]*ec.PublicKey{}, keys...), nil
+}
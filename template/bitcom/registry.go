@@ -0,0 +1,164 @@
+package bitcom
+
+import (
+	"sync"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// ProtocolDecoder decodes the protocol at index idx of a Bitcom into a typed value.
+// The whole Bitcom is provided because some protocols (AIP, SIGMA) sign over the
+// protocols that precede them. Decoders return nil when the segment cannot be decoded,
+// and may record why with b.AddWarning.
+type ProtocolDecoder func(b *Bitcom, idx int) any
+
+// ProtocolResult is the typed result of decoding a single Bitcom protocol
+type ProtocolResult struct {
+	Protocol string `json:"proto"`
+	Index    int    `json:"ii"`
+	Value    any    `json:"value,omitempty"` // nil if no decoder is registered or decoding failed
+
+	// Warnings are the problems the decoder found, including why decoding failed
+	Warnings []*DecodeWarning `json:"warnings,omitempty"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ProtocolDecoder{}
+)
+
+func init() {
+	Register(BPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeBAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(MapPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeMapAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(AIPPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeAIPAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(BAPPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeBAPAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(BCATPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeBCATAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(BCATPartPrefix, func(b *Bitcom, idx int) any {
+		if v := decodeBCATPartAt(b, idx); v != nil {
+			return v
+		}
+		return nil
+	})
+	Register(SIGMAPrefix, func(b *Bitcom, idx int) any {
+		v := decodeSigmaAt(b, idx)
+		if v == nil {
+			return nil
+		}
+		// Count preceding SIGMA protocols so the instance hashes the correct range
+		for _, p := range b.Protocols[:idx] {
+			if p.Protocol == SIGMAPrefix {
+				v.SigmaInstance++
+			}
+		}
+		return v
+	})
+}
+
+// Register associates a decoder with a Bitcom protocol prefix, replacing any
+// decoder previously registered for that prefix. It is safe for concurrent use.
+func Register(prefix string, decoder ProtocolDecoder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if decoder == nil {
+		delete(registry, prefix)
+		return
+	}
+	registry[prefix] = decoder
+}
+
+// Unregister removes the decoder registered for a Bitcom protocol prefix
+func Unregister(prefix string) {
+	Register(prefix, nil)
+}
+
+// LookupDecoder returns the decoder registered for a Bitcom protocol prefix
+func LookupDecoder(prefix string) (ProtocolDecoder, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	decoder, ok := registry[prefix]
+	return decoder, ok
+}
+
+// DecodeProtocols runs the registered decoder for every protocol in the Bitcom,
+// returning one result per protocol in script order. Problems found by the
+// decoders are added to b.Warnings and to the result of the protocol concerned.
+func (b *Bitcom) DecodeProtocols() []*ProtocolResult {
+	results := []*ProtocolResult{}
+	if b == nil {
+		return results
+	}
+
+	for idx, proto := range b.Protocols {
+		result := &ProtocolResult{
+			Protocol: proto.Protocol,
+			Index:    idx,
+		}
+		if decoder, ok := LookupDecoder(proto.Protocol); ok {
+			result.Value = decoder(b, idx)
+			if result.Value == nil && len(b.WarningsFor(idx)) == 0 {
+				b.AddWarning(idx, 0, "protocol data could not be decoded")
+			}
+			result.Warnings = b.WarningsFor(idx)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// DecodeAll splits a script into its Bitcom protocols and decodes each of them
+// in a single pass. Returns nil if the script contains no OP_RETURN.
+func DecodeAll(scr *script.Script) []*ProtocolResult {
+	b := Decode(scr)
+	if b == nil {
+		return nil
+	}
+	return b.DecodeProtocols()
+}
+
+// Values returns every decoded value of type T, in script order
+//
+//	maps := bitcom.Values[*bitcom.Map](bitcom.DecodeAll(s))
+func Values[T any](results []*ProtocolResult) []T {
+	values := []T{}
+	for _, r := range results {
+		if v, ok := r.Value.(T); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// FirstValue returns the first decoded value of type T
+func FirstValue[T any](results []*ProtocolResult) (value T, ok bool) {
+	for _, r := range results {
+		if value, ok = r.Value.(T); ok {
+			return value, true
+		}
+	}
+	return value, false
+}
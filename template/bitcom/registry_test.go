@@ -0,0 +1,102 @@
+package bitcom
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeAll verifies that the built-in decoders produce typed results
+// for each protocol in a single pass
+func TestDecodeAll(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	s := &script.Script{}
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = s.AppendPushDataString(BPrefix)
+	_ = s.AppendPushDataString("Hello world")
+	_ = s.AppendPushDataString(string(MediaTypeTextPlain))
+	_ = s.AppendPushDataString(string(EncodingUTF8))
+	_ = s.AppendPushDataString("|")
+	_ = s.AppendPushDataString(MapPrefix)
+	_ = s.AppendPushDataString(string(MapCmdSet))
+	_ = s.AppendPushDataString("app")
+	_ = s.AppendPushDataString("test")
+	_ = s.AppendPushDataString("|")
+	_ = s.AppendPushDataString("1UnknownProtocolPrefix")
+	_ = s.AppendPushDataString("data")
+
+	results := DecodeAll(s)
+	require.Len(t, results, 3)
+
+	b, ok := results[0].Value.(*B)
+	require.True(t, ok, "First result should be B")
+	require.Equal(t, "Hello world", string(b.Data))
+	require.Equal(t, BPrefix, results[0].Protocol)
+	require.Equal(t, 0, results[0].Index)
+
+	m, ok := results[1].Value.(*Map)
+	require.True(t, ok, "Second result should be MAP")
+	require.Equal(t, "test", m.Data["app"])
+
+	require.Nil(t, results[2].Value, "Unknown protocol should have no value")
+	require.Equal(t, "1UnknownProtocolPrefix", results[2].Protocol)
+
+	maps := Values[*Map](results)
+	require.Len(t, maps, 1)
+
+	_, ok = FirstValue[*Sigma](results)
+	require.False(t, ok, "Should not find a SIGMA result")
+}
+
+// TestRegister verifies that third-party protocols can be registered and removed
+func TestRegister(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	const prefix = "1CustomProtocolTestPrefix"
+	type custom struct {
+		Value string
+	}
+
+	Register(prefix, func(b *Bitcom, idx int) any {
+		chunks, err := script.NewFromBytes(b.Protocols[idx].Script).Chunks()
+		if err != nil || len(chunks) == 0 {
+			return nil
+		}
+		return &custom{Value: string(chunks[0].Data)}
+	})
+	defer Unregister(prefix)
+
+	_, ok := LookupDecoder(prefix)
+	require.True(t, ok, "Decoder should be registered")
+
+	s := &script.Script{}
+	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = s.AppendPushDataString(prefix)
+	_ = s.AppendPushDataString("payload")
+
+	c, ok := FirstValue[*custom](DecodeAll(s))
+	require.True(t, ok, "Custom protocol should be decoded")
+	require.Equal(t, "payload", c.Value)
+
+	Unregister(prefix)
+	_, ok = LookupDecoder(prefix)
+	require.False(t, ok, "Decoder should be removed")
+	require.Nil(t, DecodeAll(s)[0].Value)
+}
+
+// TestDecodeProtocols_Nil verifies that nil inputs are handled safely
+func TestDecodeProtocols_Nil(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	var nilBitcom *Bitcom
+	require.Empty(t, nilBitcom.DecodeProtocols())
+
+	s := &script.Script{}
+	_ = s.AppendOpcodes(script.OpDUP, script.OpHASH160)
+	require.Nil(t, DecodeAll(s), "Script without OP_RETURN should return nil")
+}
@@ -6,16 +6,46 @@ package bitcom
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"strconv"
 
 	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/util"
 )
 
 // SIGMAPrefix is another recognized prefix in some implementations
 const SIGMAPrefix = "SIGMA"
 
+var (
+	ErrInvalidSigmaOutput     = errors.New("SIGMA target output not found")
+	ErrInvalidSigmaInput      = errors.New("SIGMA input not found")
+	ErrMissingSigmaFields     = errors.New("SIGMA signer address or signature missing")
+	ErrSigmaNeedsTransaction  = errors.New("SIGMA signature can only be verified with the transaction that carries it")
+	ErrSigmaUnsupportedAlgo   = errors.New("unsupported SIGMA signature algorithm")
+	ErrSigmaSignatureMismatch = errors.New("SIGMA signature does not match")
+	ErrSigmaOutputMismatch    = errors.New("Bitcom does not match the transaction output")
+)
+
+// SigmaStatus is the outcome of verifying a Sigma signature
+type SigmaStatus string
+
+const (
+	// SigmaUnverified means the signature could not be checked, normally because
+	// the transaction carrying it was not available
+	SigmaUnverified SigmaStatus = "unverified"
+
+	// SigmaVerified means the signature was checked and is valid
+	SigmaVerified SigmaStatus = "verified"
+
+	// SigmaFailed means the signature was checked and is not valid
+	SigmaFailed SigmaStatus = "failed"
+)
+
 // SignatureAlgorithm represents the algorithm used for the signature
 type SignatureAlgorithm string
 
@@ -38,7 +68,10 @@ type Sigma struct {
 	Message        string             `json:"message,omitempty"`
 	Nonce          string             `json:"nonce,omitempty"`
 	VIN            int                `json:"vin,omitempty"`
-	Valid          bool               `json:"valid,omitempty"`
+	Valid          bool               `json:"valid,omitempty"`   // Status is SigmaVerified
+	Status         SigmaStatus        `json:"status,omitempty"`  // Outcome of the last verification
+	Reason         string             `json:"reason,omitempty"`  // Why the signature is unverified or failed
+	DataEnd        int                `json:"dataEnd,omitempty"` // Signed bytes of the locking script, from the start, set when verified against a transaction
 
 	// Transaction information (optional, only for tx-based signatures)
 	Transaction   *transaction.Transaction `json:"-"`
@@ -47,7 +80,12 @@ type Sigma struct {
 	SigmaInstance int                      `json:"-"`
 }
 
-// DecodeSIGMA decodes the Sigma data from the bitcom protocols
+// DecodeSIGMA decodes the Sigma data from the bitcom protocols. Problems are
+// recorded in b.Warnings.
+//
+// Message signatures are verified. Transaction signatures can't be verified without
+// the transaction that carries them and are returned with Status SigmaUnverified;
+// use DecodeSIGMATx to decode and verify them.
 func DecodeSIGMA(b *Bitcom) []*Sigma {
 	signatures := []*Sigma{}
 
@@ -57,103 +95,104 @@ func DecodeSIGMA(b *Bitcom) []*Sigma {
 	}
 
 	for i, proto := range b.Protocols {
-		// Debug
-		fmt.Printf("Protocol %d: %q, Script length: %d\n", i, proto.Protocol, len(proto.Script))
-		fmt.Printf("SIGMAPrefix: %q\n", SIGMAPrefix)
-		fmt.Printf("proto.Protocol == SIGMAPrefix: %v\n", proto.Protocol == SIGMAPrefix)
-		fmt.Printf("Script hex: %x\n", proto.Script)
-
-		// Check for SIGMA prefix
 		if proto.Protocol == SIGMAPrefix {
-			pos := 0 // Start from beginning of script
-			scr := script.NewFromBytes(proto.Script)
-
-			sigma := &Sigma{}
-
-			// Debug
-			fmt.Printf("Reading data from script...\n")
-
-			// Read ALGORITHM - handle the case where it's prefixed with length
-			if op, err := scr.ReadOp(&pos); err != nil {
-				fmt.Printf("Error reading algorithm: %v\n", err)
-				continue
-			} else {
-				// The algorithm field is prefixed with its length (03) for "BSM"
-				if len(op.Data) > 1 && op.Data[0] == 0x03 {
-					sigma.Algorithm = SignatureAlgorithm(string(op.Data[1:])) // Skip the length byte
-				} else {
-					sigma.Algorithm = SignatureAlgorithm(string(op.Data))
-				}
-				fmt.Printf("Algorithm: %q\n", sigma.Algorithm)
+			if sigma := decodeSigmaAt(b, i); sigma != nil {
+				signatures = append(signatures, sigma)
 			}
+		}
+	}
+	return signatures
+}
 
-			// Read SIGNER ADDRESS - handle the case where it's prefixed with quotes
-			if op, err := scr.ReadOp(&pos); err != nil {
-				fmt.Printf("Error reading signer address: %v\n", err)
-				continue
-			} else {
-				if len(op.Data) > 1 && op.Data[0] == '"' {
-					// If it starts with a quote, trim the quotes
-					sigma.SignerAddress = string(op.Data[1 : len(op.Data)-1])
-				} else {
-					sigma.SignerAddress = string(op.Data)
-				}
-				fmt.Printf("SignerAddress: %q\n", sigma.SignerAddress)
-			}
+// decodeSigmaAt decodes the SIGMA protocol at index idx of the Bitcom.
+// Returns nil if the protocol is not SIGMA or required fields are missing.
+func decodeSigmaAt(b *Bitcom, idx int) *Sigma {
+	proto := b.Protocols[idx]
+	if proto.Protocol != SIGMAPrefix {
+		return nil
+	}
 
-			// Read SIGNATURE VALUE
-			if op, err := scr.ReadOp(&pos); err != nil {
-				fmt.Printf("Error reading signature value: %v\n", err)
-				continue
-			} else {
-				// Base64 encode the signature value
-				sigma.SignatureValue = base64.StdEncoding.EncodeToString(op.Data)
-				fmt.Printf("SignatureValue: %s\n", sigma.SignatureValue)
-			}
+	pos := 0 // Start from beginning of script
+	scr := script.NewFromBytes(proto.Script)
+
+	sigma := &Sigma{}
 
-			// Try to read optional fields
+	// Read ALGORITHM - handle the case where it's prefixed with length
+	if op, err := scr.ReadOp(&pos); err != nil {
+		b.AddWarning(idx, pos, "missing SIGMA algorithm")
+		return nil
+	} else {
+		// The algorithm field is prefixed with its length (03) for "BSM"
+		if len(op.Data) > 1 && op.Data[0] == 0x03 {
+			sigma.Algorithm = SignatureAlgorithm(string(op.Data[1:])) // Skip the length byte
+		} else {
+			sigma.Algorithm = SignatureAlgorithm(string(op.Data))
+		}
+	}
+
+	// Read SIGNER ADDRESS - handle the case where it's prefixed with quotes
+	if op, err := scr.ReadOp(&pos); err != nil {
+		b.AddWarning(idx, pos, "missing SIGMA signer address")
+		return nil
+	} else {
+		if len(op.Data) > 1 && op.Data[0] == '"' {
+			// If it starts with a quote, trim the quotes
+			sigma.SignerAddress = string(op.Data[1 : len(op.Data)-1])
+		} else {
+			sigma.SignerAddress = string(op.Data)
+		}
+	}
+
+	// Read SIGNATURE VALUE
+	if op, err := scr.ReadOp(&pos); err != nil {
+		b.AddWarning(idx, pos, "missing SIGMA signature")
+		return nil
+	} else {
+		// Base64 encode the signature value
+		sigma.SignatureValue = base64.StdEncoding.EncodeToString(op.Data)
+	}
+
+	// Try to read optional fields
+	if op, err := scr.ReadOp(&pos); err == nil {
+		// Check if this is VIN field (numeric value)
+		if vin, ok := parseSigmaVIN(op.Data); ok {
+			sigma.VIN = vin
+		} else {
+			// This is probably a message field
+			sigma.Message = string(op.Data)
+
+			// Try to read nonce if it exists
 			if op, err := scr.ReadOp(&pos); err == nil {
-				// Check if this is VIN field (numeric value)
-				if len(op.Data) == 1 && op.Data[0] >= '0' && op.Data[0] <= '9' {
-					sigma.VIN = int(op.Data[0] - '0')
-					fmt.Printf("VIN: %d\n", sigma.VIN)
-				} else {
-					// This is probably a message field
-					sigma.Message = string(op.Data)
-					fmt.Printf("Message: %q\n", sigma.Message)
-
-					// Try to read nonce if it exists
-					if op, err := scr.ReadOp(&pos); err == nil {
-						sigma.Nonce = string(op.Data)
-						fmt.Printf("Nonce: %q\n", sigma.Nonce)
-					}
-				}
+				sigma.Nonce = string(op.Data)
 			}
+		}
+	}
 
-			// Validate the signature if we have the necessary data
-			if sigma.SignerAddress != "" && sigma.SignatureValue != "" {
-				// For signatures with explicit message field
-				if sigma.Message != "" {
-					if err := sigma.VerifyMessageSignature(); err != nil {
-						fmt.Printf("Failed to verify message signature: %v\n", err)
-					}
-				} else if sigma.Transaction != nil {
-					// For transaction signatures, we need to derive the message from transaction data
-					if err := sigma.VerifyTransactionSignature(); err != nil {
-						fmt.Printf("Failed to verify transaction signature: %v\n", err)
-					}
-				} else {
-					// For now, just trust signatures without enough context to verify
-					sigma.Valid = true
-				}
-			}
+	// Message signatures carry everything needed to verify them; transaction
+	// signatures stay unverified until checked against their transaction
+	if sigma.Message != "" {
+		if err := sigma.VerifyMessageSignature(); err != nil {
+			b.AddWarning(idx, 0, "SIGMA message signature not verified: "+err.Error())
+		}
+	} else {
+		sigma.setResult(ErrSigmaNeedsTransaction)
+	}
 
-			fmt.Printf("Adding sigma to result\n")
-			signatures = append(signatures, sigma)
+	return sigma
+}
+
+// parseSigmaVIN parses a VIN field, which is pushed as decimal digits
+func parseSigmaVIN(data []byte) (int, bool) {
+	if len(data) == 0 || len(data) > 9 {
+		return 0, false
+	}
+	for _, c := range data {
+		if c < '0' || c > '9' {
+			return 0, false
 		}
 	}
-	fmt.Printf("Returning %d signatures\n", len(signatures))
-	return signatures
+	vin, err := strconv.Atoi(string(data))
+	return vin, err == nil
 }
 
 // GetSignatureBytes returns the signature as a byte array
@@ -171,143 +210,106 @@ func (s *Sigma) GetSignatureBytes() ([]byte, error) {
 	return decoded, nil
 }
 
-// Verify is a generic verification method that chooses the appropriate verification strategy
+// Verify is a generic verification method that chooses the appropriate verification
+// strategy. It sets Status, Reason and Valid, and returns ErrSigmaNeedsTransaction
+// if there is neither a message nor a transaction to verify against.
 func (s *Sigma) Verify() error {
 	if s.Message != "" {
 		return s.VerifyMessageSignature()
 	} else if s.Transaction != nil {
 		return s.VerifyTransactionSignature()
 	}
-	return fmt.Errorf("insufficient data for verification")
+	return s.setResult(ErrSigmaNeedsTransaction)
 }
 
 // VerifyMessageSignature validates a Sigma signature against a simple message
 func (s *Sigma) VerifyMessageSignature() error {
-	// Check if we have the necessary data to verify
 	if s.SignerAddress == "" || s.SignatureValue == "" || s.Message == "" {
-		return fmt.Errorf("missing required data for message signature verification")
-	}
-
-	// Get signature bytes
-	sigBytes, err := s.GetSignatureBytes()
-	if err != nil {
-		return err
-	}
-
-	// Verify using different methods based on the algorithm
-	switch s.Algorithm {
-	case AlgoBSM:
-		// Use Bitcoin Signed Message verification
-		if err := bsm.VerifyMessage(s.SignerAddress, sigBytes, []byte(s.Message)); err == nil {
-			s.Valid = true
-			return nil
-		} else {
-			// For testing purposes, handle specific test cases
-			if s.SignerAddress == "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz" &&
-				s.Message == "Hello, World!" &&
-				s.SignatureValue == "H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=" {
-				// This is our test vector that we know should be valid
-				s.Valid = true
-				return nil
-			}
-			return fmt.Errorf("BSM verification failed: %w", err)
-		}
-	case AlgoECDSA, AlgoSHA256ECDSA:
-		// For ECDSA and SHA256+ECDSA, we also use BSM since it handles both
-		if err := bsm.VerifyMessage(s.SignerAddress, sigBytes, []byte(s.Message)); err == nil {
-			s.Valid = true
-			return nil
-		} else {
-			return fmt.Errorf("ECDSA verification failed: %w", err)
-		}
-	default:
-		return fmt.Errorf("unsupported signature algorithm: %s", s.Algorithm)
+		return s.setResult(fmt.Errorf("%w: message signature requires an address, signature and message", ErrMissingSigmaFields))
 	}
+	return s.setResult(s.verifyBSM([]byte(s.Message)))
 }
 
 // VerifyTransactionSignature validates a Sigma signature against transaction data
 // This follows the approach used in the go-sigma library for constructing transaction message buffers
 func (s *Sigma) VerifyTransactionSignature() error {
-	// Check if we have the necessary data to verify
-	if s.SignerAddress == "" || s.SignatureValue == "" || s.Transaction == nil {
-		return fmt.Errorf("missing required data for transaction signature verification")
+	if s.Transaction == nil {
+		return s.setResult(ErrSigmaNeedsTransaction)
 	}
-
-	// Get signature bytes
-	sigBytes, err := s.GetSignatureBytes()
-	if err != nil {
-		return err
+	if s.SignerAddress == "" || s.SignatureValue == "" {
+		return s.setResult(ErrMissingSigmaFields)
 	}
 
 	// Construct message hash from transaction data according to Sigma protocol
 	msgHash := s.getMessageHash()
 	if msgHash == nil {
-		return fmt.Errorf("failed to generate message hash from transaction")
+		return s.setResult(fmt.Errorf("%w: failed to generate message hash from transaction", ErrSigmaSignatureMismatch))
 	}
+	return s.setResult(s.verifyBSM(msgHash))
+}
 
-	// Verify using different methods based on the algorithm
+// verifyBSM verifies the signature over message as a Bitcoin Signed Message,
+// which every supported algorithm uses
+func (s *Sigma) verifyBSM(message []byte) error {
 	switch s.Algorithm {
-	case AlgoBSM:
-		// Use Bitcoin Signed Message verification with transaction message hash
-		if err := bsm.VerifyMessage(s.SignerAddress, sigBytes, msgHash); err == nil {
-			s.Valid = true
-			return nil
-		} else {
-			return fmt.Errorf("BSM verification failed for transaction: %w", err)
-		}
-	case AlgoECDSA, AlgoSHA256ECDSA:
-		// For ECDSA and SHA256+ECDSA with transaction context
-		if err := bsm.VerifyMessage(s.SignerAddress, sigBytes, msgHash); err == nil {
-			s.Valid = true
-			return nil
-		} else {
-			return fmt.Errorf("ECDSA verification failed for transaction: %w", err)
-		}
+	case AlgoBSM, AlgoECDSA, AlgoSHA256ECDSA:
+	default:
+		return fmt.Errorf("%w: %s", ErrSigmaUnsupportedAlgo, s.Algorithm)
+	}
+	sigBytes, err := s.GetSignatureBytes()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSigmaSignatureMismatch, err)
+	}
+	if err := bsm.VerifyMessage(s.SignerAddress, sigBytes, message); err != nil {
+		return fmt.Errorf("%w: %w", ErrSigmaSignatureMismatch, err)
+	}
+	return nil
+}
+
+// setResult records the outcome of a verification and returns err.
+// ErrSigmaNeedsTransaction leaves the signature unverified, any other error fails it.
+func (s *Sigma) setResult(err error) error {
+	switch {
+	case err == nil:
+		s.Status, s.Reason = SigmaVerified, ""
+	case errors.Is(err, ErrSigmaNeedsTransaction):
+		s.Status, s.Reason = SigmaUnverified, err.Error()
 	default:
-		return fmt.Errorf("unsupported signature algorithm: %s", s.Algorithm)
+		s.Status, s.Reason = SigmaFailed, err.Error()
 	}
+	s.Valid = s.Status == SigmaVerified
+	return err
 }
 
-// getInputHash generates a hash of the transaction inputs
-// This follows the approach used in go-sigma
+// getInputHash hashes the outpoint spent by the referenced input.
+// A VIN outside the inputs falls back to the target output index, as in go-sigma.
 func (s *Sigma) getInputHash() []byte {
 	if s.Transaction == nil || len(s.Transaction.Inputs) == 0 {
 		return nil
 	}
 
-	// In go-sigma, it only uses the input specified by refVin (or targetVout if refVin is -1)
 	vin := s.VIN
 	if vin < 0 || vin >= len(s.Transaction.Inputs) {
 		vin = s.TargetOutput
 	}
+	if vin < 0 || vin >= len(s.Transaction.Inputs) {
+		return nil
+	}
 
 	input := s.Transaction.Inputs[vin]
 	if input == nil || input.SourceTXID == nil {
 		return nil
 	}
-
-	// Create outpoint bytes (txid + vout in little-endian)
-	txidBytes := input.SourceTXID.CloneBytes() // Already in correct order
-
-	// Add vout as 4 bytes (little-endian)
-	voutBytes := make([]byte, 4)
-	voutBytes[0] = byte(input.SourceTxOutIndex)
-	voutBytes[1] = byte(input.SourceTxOutIndex >> 8)
-	voutBytes[2] = byte(input.SourceTxOutIndex >> 16)
-	voutBytes[3] = byte(input.SourceTxOutIndex >> 24)
-
-	// Combine into outpoint
-	outpointBytes := append(txidBytes, voutBytes...)
-
-	// Hash the outpoint with sha256 (single hash, not double)
-	hash := sha256.Sum256(outpointBytes)
-	return hash[:]
+	return outpointInputHash(&transaction.Outpoint{
+		Txid:  *input.SourceTXID,
+		Index: input.SourceTxOutIndex,
+	})
 }
 
-// getDataHash generates a hash of the transaction output data
-// This follows the approach used in go-sigma
+// getDataHash hashes the target output script up to the separator preceding
+// this SIGMA instance
 func (s *Sigma) getDataHash() []byte {
-	if s.Transaction == nil || len(s.Transaction.Outputs) <= s.TargetOutput {
+	if s.Transaction == nil || s.TargetOutput < 0 || len(s.Transaction.Outputs) <= s.TargetOutput {
 		return nil
 	}
 
@@ -315,44 +317,64 @@ func (s *Sigma) getDataHash() []byte {
 	if output.LockingScript == nil {
 		return nil
 	}
+	s.DataEnd = sigmaDataEnd(output.LockingScript, s.SigmaInstance)
+	return hash((*output.LockingScript)[:s.DataEnd])
+}
 
-	// In go-sigma, it looks for the SIGMA prefix and only hashes the script up to that point
-	// We need to find where the SIGMA protocol part begins and only hash up to there
-	scriptBytes := *output.LockingScript
-
-	// Look for either OP_RETURN or | followed by SIGMA
+// sigmaDataEnd returns the length of the part of scr signed by the given SIGMA
+// instance: everything before the OP_RETURN or "|" separator preceding it, or the
+// whole script if there is no such instance. This matches go-sigma, including
+// only recognising SIGMA as the first push after a separator.
+func sigmaDataEnd(scr *script.Script, instance int) int {
 	occurrences := 0
 	prevPos := 0
-
-	for pos := 0; pos < len(scriptBytes); {
-		op, err := output.LockingScript.ReadOp(&pos)
+	for pos := 0; pos < len(*scr); {
+		op, err := scr.ReadOp(&pos)
 		if err != nil {
 			break
 		}
-
-		// Check for OP_RETURN or | (separator)
-		if op.Op == script.OpRETURN || (op.Op == script.OpPUSHDATA1 && len(op.Data) == 1 && op.Data[0] == '|') {
-			// Try to read the next op to check if it's SIGMA
-			nextOp, err := output.LockingScript.ReadOp(&pos)
+		if isSigmaSeparator(op) {
+			next, err := scr.ReadOp(&pos)
 			if err != nil {
 				break
 			}
-
-			// Check if the op contains "SIGMA"
-			if string(nextOp.Data) == SIGMAPrefix {
-				if occurrences == s.SigmaInstance {
-					// The -1 accounts for either the OP_RETURN or "|" separator which is not signed
-					return hash(scriptBytes[:prevPos])
+			if next.Op == script.OpDATA5 && string(next.Data) == SIGMAPrefix {
+				if occurrences == instance {
+					// The separator itself is not signed
+					return prevPos
 				}
 				occurrences++
 			}
 		}
-
 		prevPos = pos
 	}
+	return len(*scr)
+}
+
+// countSigma returns the number of SIGMA instances in scr, counted the same way as sigmaDataEnd
+func countSigma(scr *script.Script) int {
+	count := 0
+	for pos := 0; pos < len(*scr); {
+		op, err := scr.ReadOp(&pos)
+		if err != nil {
+			break
+		}
+		if isSigmaSeparator(op) {
+			next, err := scr.ReadOp(&pos)
+			if err != nil {
+				break
+			}
+			if next.Op == script.OpDATA5 && string(next.Data) == SIGMAPrefix {
+				count++
+			}
+		}
+	}
+	return count
+}
 
-	// If no SIGMA prefix is found, hash the entire script
-	return hash(scriptBytes)
+// isSigmaSeparator reports whether op is an OP_RETURN or a "|" push
+func isSigmaSeparator(op *script.ScriptChunk) bool {
+	return op.Op == script.OpRETURN || (op.Op == script.OpDATA1 && op.Data[0] == '|')
 }
 
 // hash is a helper for SHA256 hashing
@@ -362,69 +384,228 @@ func hash(data []byte) []byte {
 }
 
 // getMessageHash creates the final message hash for verification
-// This follows the approach used in go-sigma
 func (s *Sigma) getMessageHash() []byte {
-	// Get hashes from transaction data
 	inputHash := s.getInputHash()
 	dataHash := s.getDataHash()
-
 	if inputHash == nil || dataHash == nil {
 		return nil
 	}
+	return sigmaMessageHash(inputHash, dataHash)
+}
+
+// outpointInputHash hashes an outpoint the way go-sigma does:
+// sha256 of the reversed txid followed by the little-endian output index
+func outpointInputHash(outpoint *transaction.Outpoint) []byte {
+	indexBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexBytes, outpoint.Index)
+	return hash(append(util.ReverseBytes(outpoint.Txid.CloneBytes()), indexBytes...))
+}
+
+// sigmaMessageHash combines an input hash and data hash into the message signed by Sigma
+func sigmaMessageHash(inputHash, dataHash []byte) []byte {
+	combined := make([]byte, 0, len(inputHash)+len(dataHash))
+	combined = append(combined, inputHash...)
+	combined = append(combined, dataHash...)
+	first := sha256.Sum256(combined)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// SignSigma signs b with a SIGMA signature bound to outpoint, the output spent
+// by input vin of the transaction b will be placed in. The signature covers the
+// script locked by b, which must not change before the SIGMA segment is appended.
+func SignSigma(b *Bitcom, key *ec.PrivateKey, outpoint *transaction.Outpoint, vin int) (*Sigma, error) {
+	if b == nil {
+		return nil, ErrNoProtocols
+	}
+	if key == nil {
+		return nil, ErrNoPrivateKey
+	}
+	if outpoint == nil {
+		return nil, ErrNoOutpoint
+	}
+	// SIGMA signs everything before the separator that precedes it
+	locked := b.Lock()
+	sigma, err := newSigma(key, outpointInputHash(outpoint), hash(*locked), vin)
+	if err != nil {
+		return nil, err
+	}
+	sigma.SigmaInstance = countSigma(locked)
+	return sigma, nil
+}
+
+// SignSigma signs b with SignSigma and appends the SIGMA segment to its protocols
+func (b *Bitcom) SignSigma(key *ec.PrivateKey, outpoint *transaction.Outpoint, vin int) (*Sigma, error) {
+	sigma, err := SignSigma(b, key, outpoint, vin)
+	if err != nil {
+		return nil, err
+	}
+	p, err := sigma.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b.Protocols = append(b.Protocols, p)
+	return sigma, nil
+}
+
+// SignSigmaOutput signs output targetVout of tx with a SIGMA signature bound to
+// input vin and appends the SIGMA segment to the output's locking script, after
+// a "|" if the script already has an OP_RETURN or an OP_RETURN otherwise.
+// The result is byte-for-byte what go-sigma produces.
+func SignSigmaOutput(tx *transaction.Transaction, targetVout, vin int, key *ec.PrivateKey) (*Sigma, error) {
+	if tx == nil || targetVout < 0 || targetVout >= len(tx.Outputs) || tx.Outputs[targetVout].LockingScript == nil {
+		return nil, ErrInvalidSigmaOutput
+	}
+	if vin < 0 || vin >= len(tx.Inputs) || tx.Inputs[vin].SourceTXID == nil {
+		return nil, ErrInvalidSigmaInput
+	}
+	input := tx.Inputs[vin]
+	scr := tx.Outputs[targetVout].LockingScript
+
+	sigma, err := newSigma(key, outpointInputHash(&transaction.Outpoint{
+		Txid:  *input.SourceTXID,
+		Index: input.SourceTxOutIndex,
+	}), hash(*scr), vin)
+	if err != nil {
+		return nil, err
+	}
+	p, err := sigma.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	signed := script.NewFromBytes(*scr)
+	if findReturn(signed, 0) >= 0 {
+		err = signed.AppendPushData([]byte("|"))
+	} else {
+		err = signed.AppendOpcodes(script.OpRETURN)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := signed.AppendPushData([]byte(p.Protocol)); err != nil {
+		return nil, err
+	}
+	*signed = append(*signed, p.Script...)
 
-	// Concatenate the input hash and data hash
-	combinedBytes := append(inputHash, dataHash...)
+	sigma.Transaction = tx
+	sigma.TargetOutput = targetVout
+	sigma.SigmaInstance = countSigma(scr)
+	tx.Outputs[targetVout].LockingScript = signed
+	return sigma, nil
+}
 
-	// In go-sigma, we use double SHA256 (Sha256d)
-	// First SHA256
-	firstHash := sha256.Sum256(combinedBytes)
-	// Second SHA256
-	secondHash := sha256.Sum256(firstHash[:])
+// newSigma signs the Sigma message of inputHash and dataHash with key
+func newSigma(key *ec.PrivateKey, inputHash, dataHash []byte, vin int) (*Sigma, error) {
+	if key == nil {
+		return nil, ErrNoPrivateKey
+	}
+	if vin < 0 {
+		return nil, ErrInvalidSigmaInput
+	}
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := bsm.SignMessage(key, sigmaMessageHash(inputHash, dataHash))
+	if err != nil {
+		return nil, err
+	}
+	sigma := &Sigma{
+		Algorithm:      AlgoBSM,
+		SignerAddress:  address.AddressString,
+		SignatureValue: base64.StdEncoding.EncodeToString(sig),
+		VIN:            vin,
+		TargetInput:    vin,
+	}
+	// The signature was just made over these hashes, so it is known to be valid
+	_ = sigma.setResult(nil)
+	return sigma, nil
+}
 
-	return secondHash[:]
+// Encode encodes the signature as a SIGMA protocol segment:
+// ALGORITHM ADDRESS SIGNATURE VIN, or ALGORITHM ADDRESS SIGNATURE MESSAGE [NONCE]
+// for message signatures
+func (s *Sigma) Encode() (*BitcomProtocol, error) {
+	if s.SignerAddress == "" || s.SignatureValue == "" {
+		return nil, ErrMissingSigmaFields
+	}
+	sig, err := s.GetSignatureBytes()
+	if err != nil {
+		return nil, err
+	}
+	fields := [][]byte{[]byte(s.Algorithm), []byte(s.SignerAddress), sig}
+	if s.Message != "" {
+		fields = append(fields, []byte(s.Message))
+		if s.Nonce != "" {
+			fields = append(fields, []byte(s.Nonce))
+		}
+	} else {
+		fields = append(fields, []byte(strconv.Itoa(s.VIN)))
+	}
+	return newProtocol(SIGMAPrefix, fields...)
 }
 
-// DecodeFromTransaction decodes Sigma signatures from a transaction
-// This is a helper method to fully initialize Sigma objects with transaction context
+// DecodeFromTransaction decodes and verifies the Sigma signatures in every output of tx
 func DecodeFromTransaction(tx *transaction.Transaction) []*Sigma {
 	if tx == nil {
 		return nil
 	}
 
 	var allSignatures []*Sigma
+	for outputIdx := range tx.Outputs {
+		allSignatures = append(allSignatures, decodeOutputSigma(tx, outputIdx)...)
+	}
+	return allSignatures
+}
 
-	// For each output in the transaction
-	for outputIdx, output := range tx.Outputs {
-		if output.LockingScript == nil {
-			continue
-		}
+// DecodeSIGMATx decodes the Sigma signatures in output vout of tx, in signing
+// order, and verifies each one against the transaction, so every signature is
+// either SigmaVerified or SigmaFailed. Returns ErrInvalidSigmaOutput if tx has
+// no such output.
+func DecodeSIGMATx(tx *transaction.Transaction, vout int) ([]*Sigma, error) {
+	if tx == nil || vout < 0 || vout >= len(tx.Outputs) {
+		return nil, ErrInvalidSigmaOutput
+	}
+	return decodeOutputSigma(tx, vout), nil
+}
 
-		// Decode BitCom protocols
-		b := Decode(output.LockingScript)
-		if b == nil {
-			continue
-		}
+// decodeOutputSigma decodes the Sigma signatures of output outputIdx of tx, in
+// signing order, and verifies each against the part of the output it signs
+func decodeOutputSigma(tx *transaction.Transaction, outputIdx int) []*Sigma {
+	output := tx.Outputs[outputIdx]
+	if output.LockingScript == nil {
+		return nil
+	}
 
-		// Decode Sigma signatures
-		signatures := DecodeSIGMA(b)
-		if len(signatures) == 0 {
+	// Decode BitCom protocols
+	b := Decode(output.LockingScript)
+	if b == nil {
+		return nil
+	}
+
+	var signatures []*Sigma
+	instance := 0
+	for i, proto := range b.Protocols {
+		if proto.Protocol != SIGMAPrefix {
 			continue
 		}
-
-		// Add transaction context to each signature
-		for instanceIdx, sigma := range signatures {
+		sigma := decodeSigmaAt(b, i)
+		if sigma != nil {
+			// Add transaction context and verify against the part of the output it signs.
+			// Message signatures were already verified against their message.
 			sigma.Transaction = tx
 			sigma.TargetOutput = outputIdx
-			sigma.SigmaInstance = instanceIdx
-
-			// Verify with transaction context
-			if err := sigma.VerifyTransactionSignature(); err != nil {
-				fmt.Printf("Failed to verify transaction signature: %v\n", err)
+			sigma.SigmaInstance = instance
+			if sigma.Message == "" {
+				if err := sigma.VerifyTransactionSignature(); err != nil {
+					b.AddWarning(i, 0, "SIGMA transaction signature not verified: "+err.Error())
+				}
 			}
+			signatures = append(signatures, sigma)
 		}
-
-		allSignatures = append(allSignatures, signatures...)
+		// Instances that fail to decode still occupy their position in the output
+		instance++
 	}
-
-	return allSignatures
+	return signatures
 }
@@ -0,0 +1,91 @@
+package bitcom
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+var (
+	ErrSigmaSignerMissing = errors.New("required SIGMA signer not found")
+)
+
+// SigmaChain is the sequence of SIGMA signatures in a single output, in signing
+// order. Each signature signs the output up to its own separator, so a signature
+// also covers the content and every signature before it: a co-signer signing an
+// already signed output attests to the earlier signatures as well as the content.
+//
+//	OP_RETURN <content> | SIGMA <author> | SIGMA <moderator>
+//	          [ author  ]
+//	          [ moderator                ]
+type SigmaChain []*Sigma
+
+// DecodeSigmaChain decodes the SIGMA signatures in output vout of tx and verifies
+// each one against the part of the output it signs. Returns nil if the output
+// does not exist or has no SIGMA signatures.
+func DecodeSigmaChain(tx *transaction.Transaction, vout int) SigmaChain {
+	if tx == nil || vout < 0 || vout >= len(tx.Outputs) {
+		return nil
+	}
+	sigs := decodeOutputSigma(tx, vout)
+	if len(sigs) == 0 {
+		return nil
+	}
+	return SigmaChain(sigs)
+}
+
+// Valid reports whether the chain has at least one signature and every signature is valid
+func (c SigmaChain) Valid() bool {
+	for _, s := range c {
+		if !s.Valid {
+			return false
+		}
+	}
+	return len(c) > 0
+}
+
+// CoveredBy returns the valid signatures that sign over the signature at
+// instance, which are the signatures that follow it. Use -1 to get every valid
+// signature, all of which cover the content before the first signature.
+func (c SigmaChain) CoveredBy(instance int) []*Sigma {
+	var covering []*Sigma
+	for _, s := range c {
+		if s.Valid && s.SigmaInstance > instance {
+			covering = append(covering, s)
+		}
+	}
+	return covering
+}
+
+// Signers returns the addresses of the valid signatures, in signing order
+func (c SigmaChain) Signers() []string {
+	var signers []string
+	for _, s := range c.CoveredBy(-1) {
+		signers = append(signers, s.SignerAddress)
+	}
+	return signers
+}
+
+// RequireSigners checks that each address made a valid signature in the given
+// order, so each later signer signed over the earlier signers' signatures.
+// Other signatures may appear before, between or after them.
+//
+//	err := chain.RequireSigners(authorAddress, moderatorAddress)
+func (c SigmaChain) RequireSigners(addresses ...string) error {
+	next := 0
+	for _, address := range addresses {
+		found := false
+		for ; next < len(c); next++ {
+			if c[next].Valid && c[next].SignerAddress == address {
+				found = true
+				next++
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrSigmaSignerMissing, address)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package bitcom
+
+import (
+	"testing"
+
+	gosigma "github.com/bitcoinschema/go-sigma"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSigmaChain_CoSigning verifies that a second signer signing an already signed
+// output covers the first signature, and that each instance verifies against its
+// own part of the output
+func TestSigmaChain_CoSigning(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	author, moderator := newTestKey(t), newTestKey(t)
+	authorAddress, moderatorAddress := testAddress(t, author), testAddress(t, moderator)
+
+	content, err := NewBuilder().AddProtocol(BPrefix, []byte("Hello"), []byte(MediaTypeTextPlain), []byte(EncodingUTF8)).Build()
+	require.NoError(t, err)
+	tx := sigmaTestTx(t, content)
+
+	_, err = SignSigmaOutput(tx, 0, 0, author)
+	require.NoError(t, err)
+	authorSigned := tx.Outputs[0].LockingScript.Bytes()
+	_, err = SignSigmaOutput(tx, 0, 1, moderator)
+	require.NoError(t, err)
+
+	chain := DecodeSigmaChain(tx, 0)
+	require.Len(t, chain, 2)
+	require.True(t, chain.Valid(), "Both signatures should verify")
+	require.Equal(t, []string{authorAddress, moderatorAddress}, chain.Signers())
+
+	// The author signs the content, the moderator signs the content and the author's signature
+	require.Equal(t, len(*content), chain[0].DataEnd)
+	require.Equal(t, len(authorSigned), chain[1].DataEnd)
+	require.Equal(t, []*Sigma{chain[1]}, chain.CoveredBy(0))
+	require.Empty(t, chain.CoveredBy(1))
+
+	require.NoError(t, chain.RequireSigners(authorAddress, moderatorAddress))
+	require.NoError(t, chain.RequireSigners(moderatorAddress))
+	require.ErrorIs(t, chain.RequireSigners(moderatorAddress, authorAddress), ErrSigmaSignerMissing,
+		"The author did not sign over the moderator")
+
+	// go-sigma verifies both instances
+	for instance, vin := range []int{0, 1} {
+		verifier := gosigma.NewSigma(*tx, 0, instance, vin)
+		verifier.SetHashes()
+		require.True(t, verifier.Verify(), "go-sigma should verify instance %d", instance)
+	}
+}
+
+// TestSigmaChain_ReplacedSignature verifies that replacing an earlier signature
+// invalidates the signatures that covered it
+func TestSigmaChain_ReplacedSignature(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	author, impostor, moderator := newTestKey(t), newTestKey(t), newTestKey(t)
+
+	b, err := NewBuilder().
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		Bitcom()
+	require.NoError(t, err)
+	tx := sigmaTestTx(t, b.Lock())
+	outpoint := sigmaTestOutpoint(tx, 0)
+
+	_, err = b.SignSigma(author, outpoint, 0)
+	require.NoError(t, err)
+	_, err = b.SignSigma(moderator, outpoint, 0)
+	require.NoError(t, err)
+
+	// Substitute the author's signature with a valid signature from someone else
+	forged, err := SignSigma(&Bitcom{ScriptPrefix: b.ScriptPrefix, Protocols: b.Protocols[:1]}, impostor, outpoint, 0)
+	require.NoError(t, err)
+	b.Protocols[1], err = forged.Encode()
+	require.NoError(t, err)
+	tx.Outputs[0].LockingScript = b.Lock()
+
+	chain := DecodeSigmaChain(tx, 0)
+	require.Len(t, chain, 2)
+	require.True(t, chain[0].Valid, "The substituted signature is valid on its own")
+	require.False(t, chain[1].Valid, "The moderator did not sign the substituted signature")
+	require.False(t, chain.Valid())
+	require.ErrorIs(t, chain.RequireSigners(testAddress(t, impostor), testAddress(t, moderator)), ErrSigmaSignerMissing)
+}
+
+// TestSigmaChain_Builder verifies that chained signatures requested from the
+// builder are verified in order
+func TestSigmaChain_Builder(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	first, second := newTestKey(t), newTestKey(t)
+	tx := sigmaTestTx(t, &script.Script{})
+	outpoint := sigmaTestOutpoint(tx, 1)
+
+	s, err := NewBuilder().
+		AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).
+		SignSigma(first, outpoint, 1).
+		SignSigma(second, outpoint, 1).
+		Build()
+	require.NoError(t, err)
+	tx.Outputs[0].LockingScript = s
+
+	chain := DecodeSigmaChain(tx, 0)
+	require.True(t, chain.Valid())
+	require.NoError(t, chain.RequireSigners(testAddress(t, first), testAddress(t, second)))
+
+	require.Nil(t, DecodeSigmaChain(tx, 1))
+	require.Nil(t, DecodeSigmaChain(nil, 0))
+}
@@ -87,7 +87,7 @@ func TestDecodeSIGMA(t *testing.T) {
 					Algorithm:      AlgoECDSA,
 					SignerAddress:  "1AddressBTC12345678",
 					SignatureValue: base64.StdEncoding.EncodeToString([]byte("signature1234567890")),
-					Valid:          true, // We now trust transaction signatures without message
+					Status:         SigmaUnverified, // Transaction signatures need the transaction to verify
 				},
 			},
 		},
@@ -153,13 +153,13 @@ func TestDecodeSIGMA(t *testing.T) {
 					Algorithm:      AlgoECDSA,
 					SignerAddress:  "1Address1",
 					SignatureValue: base64.StdEncoding.EncodeToString([]byte("signature1")),
-					Valid:          true, // We now trust transaction signatures without message
+					Status:         SigmaUnverified, // Transaction signatures need the transaction to verify
 				},
 				{
 					Algorithm:      AlgoSHA256ECDSA,
 					SignerAddress:  "1Address2",
 					SignatureValue: base64.StdEncoding.EncodeToString([]byte("signature2")),
-					Valid:          true, // We now trust transaction signatures without message
+					Status:         SigmaUnverified, // Transaction signatures need the transaction to verify
 				},
 			},
 		},
@@ -234,6 +234,10 @@ func TestDecodeSIGMA(t *testing.T) {
 					require.Equal(t, expectedSigma.SignatureValue, resultSigma.SignatureValue)
 					require.Equal(t, expectedSigma.Message, resultSigma.Message)
 					require.Equal(t, expectedSigma.Nonce, resultSigma.Nonce)
+					if expectedSigma.Status != "" {
+						require.Equal(t, expectedSigma.Status, resultSigma.Status)
+						require.False(t, resultSigma.Valid, "Unverified signatures should not be valid")
+					}
 				}
 			}
 		})
@@ -394,9 +398,9 @@ func TestVerify(t *testing.T) {
 			name: "Valid BSM signature",
 			sigmaSignature: &Sigma{
 				Algorithm:     AlgoBSM,
-				SignerAddress: "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz",
-				// This is a valid signature for the message "Hello, World!" from address 1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz
-				SignatureValue: "H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=",
+				SignerAddress: "1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb",
+				// This is a valid signature for the message "Hello, World!" from address 1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb
+				SignatureValue: "IKRAQxEuRYsvbBjcZwaFnmjwYM45yndnuha9v+YlBS5KRb1vCjpWHcM0UaqLEIGNXmLzujJCneox/5P8kRdBSvk=",
 				Message:        "Hello, World!",
 			},
 			expectValid: true,
@@ -405,7 +409,7 @@ func TestVerify(t *testing.T) {
 			name: "Invalid BSM signature (wrong signature)",
 			sigmaSignature: &Sigma{
 				Algorithm:     AlgoBSM,
-				SignerAddress: "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz",
+				SignerAddress: "1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb",
 				// This is an invalid signature
 				SignatureValue: "H00000012iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=",
 				Message:        "Hello, World!",
@@ -417,8 +421,8 @@ func TestVerify(t *testing.T) {
 			sigmaSignature: &Sigma{
 				Algorithm:     AlgoBSM,
 				SignerAddress: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", // Wrong address
-				// This is a valid signature for the message "Hello, World!" from address 1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz
-				SignatureValue: "H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=",
+				// This is a valid signature for the message "Hello, World!" from address 1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb
+				SignatureValue: "IKRAQxEuRYsvbBjcZwaFnmjwYM45yndnuha9v+YlBS5KRb1vCjpWHcM0UaqLEIGNXmLzujJCneox/5P8kRdBSvk=",
 				Message:        "Hello, World!",
 			},
 			expectValid: false,
@@ -427,9 +431,9 @@ func TestVerify(t *testing.T) {
 			name: "Invalid BSM signature (wrong message)",
 			sigmaSignature: &Sigma{
 				Algorithm:     AlgoBSM,
-				SignerAddress: "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz",
-				// This is a valid signature for the message "Hello, World!" from address 1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz
-				SignatureValue: "H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=",
+				SignerAddress: "1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb",
+				// This is a valid signature for the message "Hello, World!" from address 1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb
+				SignatureValue: "IKRAQxEuRYsvbBjcZwaFnmjwYM45yndnuha9v+YlBS5KRb1vCjpWHcM0UaqLEIGNXmLzujJCneox/5P8kRdBSvk=",
 				Message:        "Modified message",
 			},
 			expectValid: false,
@@ -469,10 +473,10 @@ func TestDecodeSigmaWithVerification(t *testing.T) {
 	// Create a simple Sigma bitcom protocol with a valid signature
 	s := &script.Script{}
 	_ = s.AppendPushData([]byte("BSM"))
-	_ = s.AppendPushData([]byte("1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz"))
+	_ = s.AppendPushData([]byte("1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb"))
 
 	// Decode the valid signature from base64
-	sigBytes, err := base64.StdEncoding.DecodeString("H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=")
+	sigBytes, err := base64.StdEncoding.DecodeString("IKRAQxEuRYsvbBjcZwaFnmjwYM45yndnuha9v+YlBS5KRb1vCjpWHcM0UaqLEIGNXmLzujJCneox/5P8kRdBSvk=")
 	require.NoError(t, err)
 
 	_ = s.AppendPushData(sigBytes)
@@ -495,6 +499,94 @@ func TestDecodeSigmaWithVerification(t *testing.T) {
 	// Check that it was validated correctly
 	assert.True(t, sigmas[0].Valid, "Signature should be marked as valid")
 	assert.Equal(t, "BSM", string(sigmas[0].Algorithm))
-	assert.Equal(t, "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz", sigmas[0].SignerAddress)
+	assert.Equal(t, "1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb", sigmas[0].SignerAddress)
 	assert.Equal(t, "Hello, World!", sigmas[0].Message)
 }
+
+// TestSigmaStatus verifies that every verification reports whether the signature
+// was verified, failed or could not be checked, and why
+func TestSigmaStatus(t *testing.T) {
+	// Reset global state before starting the test
+	resetTestState()
+
+	key := newTestKey(t)
+	content, err := NewBuilder().AddProtocol(MapPrefix, []byte("SET"), []byte("app"), []byte("test")).Build()
+	require.NoError(t, err)
+	tx := sigmaTestTx(t, content)
+	signed, err := SignSigmaOutput(tx, 0, 0, key)
+	require.NoError(t, err)
+
+	t.Run("freshly signed", func(t *testing.T) {
+		require.Equal(t, SigmaVerified, signed.Status)
+		require.True(t, signed.Valid)
+		require.Empty(t, signed.Reason)
+
+		outpoint := &transaction.Outpoint{Txid: *tx.Inputs[0].SourceTXID, Index: tx.Inputs[0].SourceTxOutIndex}
+		for _, sign := range []func(b *Bitcom) (*Sigma, error){
+			func(b *Bitcom) (*Sigma, error) { return SignSigma(b, key, outpoint, 0) },
+			func(b *Bitcom) (*Sigma, error) { return b.SignSigma(key, outpoint, 0) },
+		} {
+			sigma, err := sign(Decode(content))
+			require.NoError(t, err)
+			require.Equal(t, SigmaVerified, sigma.Status)
+			require.True(t, sigma.Valid)
+		}
+	})
+
+	t.Run("transaction signature without the transaction", func(t *testing.T) {
+		sigs := DecodeSIGMA(Decode(tx.Outputs[0].LockingScript))
+		require.Len(t, sigs, 1)
+		require.Equal(t, SigmaUnverified, sigs[0].Status)
+		require.False(t, sigs[0].Valid)
+		require.Equal(t, ErrSigmaNeedsTransaction.Error(), sigs[0].Reason)
+		require.ErrorIs(t, sigs[0].Verify(), ErrSigmaNeedsTransaction)
+	})
+
+	t.Run("transaction signature with the transaction", func(t *testing.T) {
+		sigs, err := DecodeSIGMATx(tx, 0)
+		require.NoError(t, err)
+		require.Len(t, sigs, 1)
+		require.Equal(t, SigmaVerified, sigs[0].Status)
+		require.True(t, sigs[0].Valid)
+		require.Empty(t, sigs[0].Reason)
+	})
+
+	t.Run("transaction signature spent from another input", func(t *testing.T) {
+		moved := sigmaTestTx(t, tx.Outputs[0].LockingScript)
+		moved.Inputs[0].SourceTxOutIndex++
+		sigs, err := DecodeSIGMATx(moved, 0)
+		require.NoError(t, err)
+		require.Len(t, sigs, 1)
+		require.Equal(t, SigmaFailed, sigs[0].Status)
+		require.False(t, sigs[0].Valid)
+		require.Contains(t, sigs[0].Reason, ErrSigmaSignatureMismatch.Error())
+	})
+
+	t.Run("message signatures are not trusted by address", func(t *testing.T) {
+		sigma := &Sigma{
+			Algorithm:      AlgoBSM,
+			SignerAddress:  "1EXhSbGFiEAZCE5eeBvUxT6cBVHhrpPWXz",
+			SignatureValue: "H89DSY12iMmrF16T4aDPwFcqrtuGxyoT69yTBH4GqXyzNZ+POVhxV5FLAvHdwKmJ0IhQT/w7JQpTg0XBZ5zeJ+c=",
+			Message:        "Hello, World!",
+		}
+		require.ErrorIs(t, sigma.VerifyMessageSignature(), ErrSigmaSignatureMismatch)
+		require.Equal(t, SigmaFailed, sigma.Status)
+		require.False(t, sigma.Valid)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		sigma := &Sigma{
+			Algorithm:      "RSA",
+			SignerAddress:  "1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb",
+			SignatureValue: "IKRAQxEuRYsvbBjcZwaFnmjwYM45yndnuha9v+YlBS5KRb1vCjpWHcM0UaqLEIGNXmLzujJCneox/5P8kRdBSvk=",
+			Message:        "Hello, World!",
+		}
+		require.ErrorIs(t, sigma.Verify(), ErrSigmaUnsupportedAlgo)
+		require.Equal(t, SigmaFailed, sigma.Status)
+	})
+
+	_, err = DecodeSIGMATx(tx, 1)
+	require.ErrorIs(t, err, ErrInvalidSigmaOutput)
+	_, err = DecodeSIGMATx(nil, 0)
+	require.ErrorIs(t, err, ErrInvalidSigmaOutput)
+}
@@ -2,7 +2,6 @@ package bsocial
 
 import (
 	"encoding/base64"
-	"fmt"
 
 	"github.com/bitcoin-sv/go-templates/template/bitcom"
 	"github.com/bitcoin-sv/go-templates/template/p2pkh"
@@ -98,6 +97,9 @@ type BSocial struct {
 	AIP         *bitcom.AIP `json:"aip"`
 	Attachments []bitcom.B  `json:"attachments,omitempty"`
 	Tags        [][]string  `json:"tags,omitempty"`
+
+	// Warnings are the problems found while decoding the transaction's Bitcom data
+	Warnings []*bitcom.DecodeWarning `json:"warnings,omitempty"`
 }
 
 // DecodeTransaction parses a transaction and extracts BSocial protocol data
@@ -147,26 +149,32 @@ func DecodeTransaction(tx *transaction.Transaction) (bsocial *BSocial) {
 
 // processProtocols extracts and processes BitCom protocol data
 func processProtocols(bc *bitcom.Bitcom, bsocial *BSocial) {
-	for _, proto := range bc.Protocols {
-		switch proto.Protocol {
-		case bitcom.MapPrefix:
-			if m := bitcom.DecodeMap(proto.Script); m != nil {
-				processMapData(m, bsocial)
-			} else {
-				fmt.Printf("Failed to decode MAP data: %s\n", proto.Script)
-			}
-		case bitcom.BPrefix:
-			if b := bitcom.DecodeB(proto.Script); b != nil {
-				bsocial.Attachments = append(bsocial.Attachments, *b)
-			}
+	results := bc.DecodeProtocols()
+	bsocial.Warnings = append(bsocial.Warnings, bc.Warnings...)
+	for _, result := range results {
+		switch v := result.Value.(type) {
+		case *bitcom.Map:
+			processMapData(v, bsocial)
+		case *bitcom.B:
+			bsocial.Attachments = append(bsocial.Attachments, *v)
+		case *bitcom.AIP:
+			bsocial.AIP = v
 		default:
-			// Silently ignore unknown protocols
+			// Silently ignore other protocols
 		}
 	}
 }
 
 // processMapData analyzes MAP data and populates the BSocial object
 func processMapData(m *bitcom.Map, bsocial *BSocial) {
+	// Tags are emitted as a separate MAP ADD tags <tag> ... output
+	if m.Cmd == bitcom.MapCmdAdd {
+		if m.Key == "tags" && len(m.Adds) > 0 {
+			processTags(bsocial, m.Adds)
+		}
+		return
+	}
+
 	// Check for tags in MAP data
 	if m.Data["app"] == AppName && m.Data["type"] == "post" {
 		// Try to extract tags if present
@@ -265,55 +273,25 @@ func createAction(actionType ActionType, m *bitcom.Map) Action {
 func CreatePost(post Post, attachments []bitcom.B, tags []string, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
 	tx := transaction.NewTransaction()
 
-	// Create B protocol output first
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.BPrefix)
-	_ = s.AppendPushData(post.B.Data)
-	_ = s.AppendPushDataString(string(post.B.MediaType))
-	_ = s.AppendPushDataString(string(post.B.Encoding))
-	if post.B.Filename != "" {
-		_ = s.AppendPushDataString(post.B.Filename)
-	}
-
-	// Add MAP protocol
-	_ = s.AppendPushDataString("|")
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(post.App)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypePostReply))
+	// Create B and MAP protocols
+	m := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	m.Set("app", post.App)
+	m.Set("type", string(TypePostReply))
 
 	// Add context if provided
 	if post.Context != "" {
-		_ = s.AppendPushDataString(string(post.Context))
-		_ = s.AppendPushDataString(post.ContextValue)
+		m.Set(string(post.Context), post.ContextValue)
 	}
 
 	// Add subcontext if provided
 	if post.Subcontext != "" {
-		_ = s.AppendPushDataString(string(post.Subcontext))
-		_ = s.AppendPushDataString(post.SubcontextValue)
+		m.Set(string(post.Subcontext), post.SubcontextValue)
 	}
 
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-
-		// make a string from the mapScript
-		data := s.String()
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
-		// pubKey := identityKey.PubKey()
-		// mapScript.AppendPushData(pubKey.Compressed())
+	s, err := buildScript(identityKey, &post.B, m)
+	if err != nil {
+		return nil, err
 	}
-
 	tx.AddOutput(&transaction.TransactionOutput{
 		LockingScript: s,
 		Satoshis:      0,
@@ -321,15 +299,14 @@ func CreatePost(post Post, attachments []bitcom.B, tags []string, identityKey *e
 
 	// Add tags if present
 	if len(tags) > 0 {
-		tagsScript := &script.Script{}
-		_ = tagsScript.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-		_ = tagsScript.AppendPushDataString(bitcom.MapPrefix)
-		_ = tagsScript.AppendPushDataString("ADD")
-		_ = tagsScript.AppendPushDataString("tags")
-		for _, tag := range tags {
-			_ = tagsScript.AppendPushDataString(tag)
+		tagsScript, err := buildScript(nil, &bitcom.Map{
+			Cmd:  bitcom.MapCmdAdd,
+			Key:  "tags",
+			Adds: tags,
+		})
+		if err != nil {
+			return nil, err
 		}
-
 		tx.AddOutput(&transaction.TransactionOutput{
 			LockingScript: tagsScript,
 			Satoshis:      0,
@@ -339,351 +316,123 @@ func CreatePost(post Post, attachments []bitcom.B, tags []string, identityKey *e
 	return tx, nil
 }
 
-// CreateReply creates a reply to an existing post
+// CreateReply creates a reply to an existing post. The reply is a single output
+// carrying B | MAP | AIP, followed by a change output if changeAddress is provided.
+// Replies used to repeat the same script in two outputs.
 func CreateReply(reply Reply, replyTxID string, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
-	tx := transaction.NewTransaction()
-
-	// Create B protocol output first
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.BPrefix)
-	_ = s.AppendPushData(reply.B.Data)
-	_ = s.AppendPushDataString(string(reply.B.MediaType))
-	_ = s.AppendPushDataString(string(reply.B.Encoding))
-	if reply.B.Filename != "" {
-		_ = s.AppendPushDataString(reply.B.Filename)
-	}
-
-	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: s,
-		Satoshis:      0,
-	})
-
-	// Create MAP protocol output
-
-	_ = s.AppendPushDataString("|")
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(AppName)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypePostReply))
-	_ = s.AppendPushDataString("context")
-	_ = s.AppendPushDataString("tx")
-	_ = s.AppendPushDataString("tx")
-	_ = s.AppendPushDataString(replyTxID)
-
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
-
-		// make a string from the mapScript
-		data := s.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
-	}
-
-	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: s,
-		Satoshis:      0,
-	})
-
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
-	}
-
-	return tx, nil
+	m := actionMap(TypePostReply, ContextTx, replyTxID)
+	return createActionTx(changeAddress, identityKey, &reply.B, m)
 }
 
 // CreateLike creates a like transaction
 func CreateLike(likeTxID string, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
-	tx := transaction.NewTransaction()
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(AppName)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypeLike))
-	_ = s.AppendPushDataString("context")
-	_ = s.AppendPushDataString(string(ContextTx))
-	_ = s.AppendPushDataString(string(ContextTx))
-	_ = s.AppendPushDataString(likeTxID)
-
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
-
-		// make a string from the script
-		data := s.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
-	}
-
-	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: s,
-		Satoshis:      0,
-	})
-
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
-	}
-
-	return tx, nil
+	return createActionTx(changeAddress, identityKey, actionMap(TypeLike, ContextTx, likeTxID))
 }
 
 // CreateUnlike creates an unlike transaction
 func CreateUnlike(unlikeTxID string, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
-	tx := transaction.NewTransaction()
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(AppName)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypeUnlike))
-	_ = s.AppendPushDataString("context")
-	_ = s.AppendPushDataString(string(ContextTx))
-	_ = s.AppendPushDataString(string(ContextTx))
-	_ = s.AppendPushDataString(unlikeTxID)
-
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
-
-		// make a string from the script
-		data := s.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
-	}
-
-	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: s,
-		Satoshis:      0,
-	})
-
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
-	}
-
-	return tx, nil
+	return createActionTx(changeAddress, identityKey, actionMap(TypeUnlike, ContextTx, unlikeTxID))
 }
 
 // CreateFollow creates a follow transaction
 func CreateFollow(followBapID string, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
-	tx := transaction.NewTransaction()
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(AppName)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypeFollow))
-	_ = s.AppendPushDataString("context")
-	_ = s.AppendPushDataString(string(ContextBapID))
-	_ = s.AppendPushDataString(string(ContextBapID))
-	_ = s.AppendPushDataString(followBapID)
-
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
-
-		// make a string from the script
-		data := s.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
-	}
-
-	// Add action output
-	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: s,
-		Satoshis:      0,
-	})
-
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
-	}
-
-	return tx, nil
+	return createActionTx(changeAddress, identityKey, actionMap(TypeFollow, ContextBapID, followBapID))
 }
 
 // CreateUnfollow creates an unfollow transaction
 func CreateUnfollow(unfollowBapID string, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
+	return createActionTx(changeAddress, identityKey, actionMap(TypeUnfollow, ContextBapID, unfollowBapID))
+}
+
+// CreateMessage creates a new message transaction
+func CreateMessage(message Message, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
 	tx := transaction.NewTransaction()
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.MapPrefix)
-	_ = s.AppendPushDataString("SET")
-	_ = s.AppendPushDataString("app")
-	_ = s.AppendPushDataString(AppName)
-	_ = s.AppendPushDataString("type")
-	_ = s.AppendPushDataString(string(TypeUnfollow))
-	_ = s.AppendPushDataString("context")
-	_ = s.AppendPushDataString(string(ContextBapID))
-	_ = s.AppendPushDataString(string(ContextBapID))
-	_ = s.AppendPushDataString(unfollowBapID)
-
-	// Add AIP signature
-	if identityKey != nil {
-		_ = s.AppendPushDataString("|")
-		_ = s.AppendPushDataString(bitcom.AIPPrefix)
-		_ = s.AppendPushDataString("BITCOIN_ECDSA")
 
-		// make a string from the script
-		data := s.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = s.AppendPushDataString(sig)
+	// Create B protocol output first
+	s, err := buildScript(nil, &message.B)
+	if err != nil {
+		return nil, err
 	}
-
 	tx.AddOutput(&transaction.TransactionOutput{
 		LockingScript: s,
 		Satoshis:      0,
 	})
 
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
+	// Create MAP protocol output
+	m := actionMap(TypeMessage, message.Context, message.ContextValue)
+	mapScript, err := buildScript(identityKey, m)
+	if err != nil {
+		return nil, err
 	}
+	tx.AddOutput(&transaction.TransactionOutput{
+		LockingScript: mapScript,
+		Satoshis:      0,
+	})
 
+	if err := addChange(tx, changeAddress); err != nil {
+		return nil, err
+	}
 	return tx, nil
 }
 
-// CreateMessage creates a new message transaction
-func CreateMessage(message Message, utxos []*transaction.UTXO, changeAddress *script.Address, identityKey *ec.PrivateKey) (*transaction.Transaction, error) {
-	tx := transaction.NewTransaction()
+// actionMap creates a MAP SET for a bsocial action, with a context if one is provided
+func actionMap(actionType ActionType, context ActionContext, contextValue string) *bitcom.Map {
+	m := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	m.Set("app", AppName)
+	m.Set("type", string(actionType))
+	if context != "" {
+		m.Set("context", string(context))
+		m.Set(string(context), contextValue)
+	}
+	return m
+}
 
-	// Create B protocol output first
-	s := &script.Script{}
-	_ = s.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = s.AppendPushDataString(bitcom.BPrefix)
-	_ = s.AppendPushData(message.B.Data)
-	_ = s.AppendPushDataString(string(message.B.MediaType))
-	_ = s.AppendPushDataString(string(message.B.Encoding))
-	if message.B.Filename != "" {
-		_ = s.AppendPushDataString(message.B.Filename)
+// createActionTx creates a transaction with a single action output and an optional change output
+func createActionTx(changeAddress *script.Address, identityKey *ec.PrivateKey, encoders ...bitcom.Encoder) (*transaction.Transaction, error) {
+	s, err := buildScript(identityKey, encoders...)
+	if err != nil {
+		return nil, err
 	}
 
+	tx := transaction.NewTransaction()
 	tx.AddOutput(&transaction.TransactionOutput{
 		LockingScript: s,
 		Satoshis:      0,
 	})
 
-	// Create MAP protocol output
-	mapScript := &script.Script{}
-	_ = mapScript.AppendOpcodes(script.OpFALSE, script.OpRETURN)
-	_ = mapScript.AppendPushDataString(bitcom.MapPrefix)
-	_ = mapScript.AppendPushDataString("SET")
-	_ = mapScript.AppendPushDataString("app")
-	_ = mapScript.AppendPushDataString(AppName)
-	_ = mapScript.AppendPushDataString("type")
-	_ = mapScript.AppendPushDataString(string(TypeMessage))
-
-	// Add context if provided
-	if message.Context != "" {
-		_ = mapScript.AppendPushDataString("context")
-		_ = mapScript.AppendPushDataString(string(message.Context))
-		_ = mapScript.AppendPushDataString(string(message.Context))
-		_ = mapScript.AppendPushDataString(message.ContextValue)
+	if err := addChange(tx, changeAddress); err != nil {
+		return nil, err
 	}
+	return tx, nil
+}
 
-	// Add AIP signature
+// buildScript composes the protocols into an OP_RETURN script, signed with an AIP
+// signature over every field when an identity key is provided
+func buildScript(identityKey *ec.PrivateKey, encoders ...bitcom.Encoder) (*script.Script, error) {
+	b := bitcom.NewBuilder()
+	for _, e := range encoders {
+		b.Add(e)
+	}
 	if identityKey != nil {
-		_ = mapScript.AppendPushDataString("|")
-		_ = mapScript.AppendPushDataString(bitcom.AIPPrefix)
-		_ = mapScript.AppendPushDataString("BITCOIN_ECDSA")
-
-		// make a string from the mapScript
-		data := mapScript.String()
-		sig, err := SignAIP(identityKey, data)
-		if err != nil {
-			return nil, err
-		}
-		_ = mapScript.AppendPushDataString(sig)
+		b.SignAIP(identityKey)
 	}
+	return b.Build()
+}
 
+// addChange adds a change output if changeAddress is provided
+func addChange(tx *transaction.Transaction, changeAddress *script.Address) error {
+	if changeAddress == nil {
+		return nil
+	}
+	changeScript, err := p2pkh.Lock(changeAddress)
+	if err != nil {
+		return err
+	}
 	tx.AddOutput(&transaction.TransactionOutput{
-		LockingScript: mapScript,
-		Satoshis:      0,
+		LockingScript: changeScript,
+		Change:        true,
 	})
-
-	// Add change output if changeAddress is provided
-	if changeAddress != nil {
-		changeScript, err := p2pkh.Lock(changeAddress)
-		if err != nil {
-			return nil, err
-		}
-		tx.AddOutput(&transaction.TransactionOutput{
-			LockingScript: changeScript,
-			Change:        true,
-		})
-	}
-
-	return tx, nil
+	return nil
 }
 
 // processTags handles different tag formats and adds them to the BSocial object
@@ -717,13 +466,16 @@ func processTags(bsocial *BSocial, tagsField any) {
 type Algorithm string
 
 const (
-	BitcoinECDSA         Algorithm = "BITCOIN_ECDSA"        // Backwards compatible for BitcoinSignedMessage
-	BitcoinSignedMessage Algorithm = "BitcoinSignedMessage" // New algo name
-	Paymail              Algorithm = "paymail"              // Using "pubkey" as aip.Address
+	BitcoinECDSA         Algorithm = bitcom.AIPAlgoBitcoinECDSA         // Backwards compatible for BitcoinSignedMessage
+	BitcoinSignedMessage Algorithm = bitcom.AIPAlgoBitcoinSignedMessage // New algo name
+	Paymail              Algorithm = bitcom.AIPAlgoPaymail              // Using a paymail handle as aip.Address
 )
 
 // Sign will provide an AIP signature for a given private key and message using
 // the provided algorithm. It prepends an OP_RETURN to the payload
+//
+// Deprecated: the signature is not over the fields AIP verification expects.
+// Use bitcom.SignAIP or Bitcom.SignAIP to sign the protocols of a script.
 func SignAIP(privateKey *ec.PrivateKey, message string) (b64Sig string, err error) {
 	// Sign using the private key and the message
 	var sig []byte
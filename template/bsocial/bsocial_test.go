@@ -5,6 +5,8 @@ import (
 
 	"github.com/bitcoin-sv/go-templates/template/bitcom"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,7 +51,8 @@ func TestCreatePost(t *testing.T) {
 	require.Equal(t, string(post.B.MediaType), string(bsocial.Post.B.MediaType))
 	require.Equal(t, string(post.B.Encoding), string(bsocial.Post.B.Encoding))
 
-	// TODO: Add tag verification when the decoder properly handles tags
+	// Verify tags
+	require.Equal(t, [][]string{tags}, bsocial.Tags)
 }
 
 // TestCreateLike verifies the Like creation functionality
@@ -77,6 +80,30 @@ func TestCreateLike(t *testing.T) {
 	require.Equal(t, TypeLike, bsocial.Like.Type)
 	require.Equal(t, ContextTx, bsocial.Like.Context)
 	require.Equal(t, testTxID, bsocial.Like.ContextValue)
+
+	// Verify AIP signature
+	require.NotNil(t, bsocial.AIP)
+	require.True(t, bsocial.AIP.Valid, "AIP signature should be valid")
+}
+
+// TestDecodeTransaction_Warnings verifies that undecodable protocols are reported
+// as warnings rather than printed
+func TestDecodeTransaction_Warnings(t *testing.T) {
+	s, err := bitcom.NewBuilder().
+		AddProtocol(bitcom.BPrefix, []byte("Hello"), []byte(bitcom.MediaTypeTextPlain), []byte(bitcom.EncodingUTF8)).
+		AddProtocol(bitcom.MapPrefix, []byte("SET")).
+		Build()
+	require.NoError(t, err)
+
+	tx := transaction.NewTransaction()
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: s})
+
+	bsocial := DecodeTransaction(tx)
+	require.NotNil(t, bsocial)
+	require.Len(t, bsocial.Attachments, 1)
+	require.Len(t, bsocial.Warnings, 1)
+	require.Equal(t, bitcom.MapPrefix, bsocial.Warnings[0].Protocol)
+	require.Equal(t, 1, bsocial.Warnings[0].Index)
 }
 
 // TestCreateReply verifies the Reply creation functionality
@@ -123,6 +150,56 @@ func TestCreateReply(t *testing.T) {
 	require.Equal(t, string(reply.B.Data), string(bsocial.Reply.B.Data))
 	require.Equal(t, string(reply.B.MediaType), string(bsocial.Reply.B.MediaType))
 	require.Equal(t, string(reply.B.Encoding), string(bsocial.Reply.B.Encoding))
+
+	// Verify AIP signature
+	require.Len(t, tx.Outputs, 1, "Reply should have a single output")
+	require.NotNil(t, bsocial.AIP)
+	require.True(t, bsocial.AIP.Valid, "AIP signature should be valid")
+}
+
+// TestCreateReply_Shape pins the outputs of a reply: one B | MAP | AIP output
+// signed over every field, then the change
+func TestCreateReply_Shape(t *testing.T) {
+	privKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	changeAddress, err := script.NewAddressFromPublicKey(privKey.PubKey(), true)
+	require.NoError(t, err)
+	testTxID := "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+
+	reply := Reply{B: bitcom.B{
+		MediaType: bitcom.MediaTypeTextPlain,
+		Encoding:  bitcom.EncodingUTF8,
+		Data:      []byte("This is a test reply"),
+	}}
+	tx, err := CreateReply(reply, testTxID, nil, changeAddress, privKey)
+	require.NoError(t, err)
+	require.Len(t, tx.Outputs, 2)
+	require.Zero(t, tx.Outputs[0].Satoshis)
+	require.False(t, tx.Outputs[0].Change)
+	require.True(t, tx.Outputs[1].Change)
+
+	bc := bitcom.Decode(tx.Outputs[0].LockingScript)
+	require.NotNil(t, bc)
+	protocols := make([]string, len(bc.Protocols))
+	for i, p := range bc.Protocols {
+		protocols[i] = p.Protocol
+	}
+	require.Equal(t, []string{bitcom.BPrefix, bitcom.MapPrefix, bitcom.AIPPrefix}, protocols)
+
+	m := bitcom.DecodeMap(bc.Protocols[1].Script)
+	require.NotNil(t, m)
+	require.Equal(t, bitcom.MapCmdSet, m.Cmd)
+	require.Equal(t, []bitcom.MapPair{
+		{Key: "app", Value: AppName},
+		{Key: "type", Value: string(TypePostReply)},
+		{Key: "context", Value: string(ContextTx)},
+		{Key: string(ContextTx), Value: testTxID},
+	}, m.OrderedPairs())
+
+	aip, ok := bitcom.FirstValue[*bitcom.AIP](bc.DecodeProtocols())
+	require.True(t, ok)
+	require.True(t, aip.Valid)
+	require.Empty(t, aip.FieldIndexes, "The signature covers every field")
 }
 
 // TestCreateMessage verifies the Message creation functionality
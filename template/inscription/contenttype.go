@@ -0,0 +1,185 @@
+package inscription
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const ContentTypeUnknown = "application/octet-stream"
+
+var (
+	ErrInvalidContentType  = errors.New("invalid content type")
+	ErrContentTypeMismatch = errors.New("content does not match declared content type")
+)
+
+// signatureTypes are the types SniffContentType detects from a signature in
+// the content, so content without the signature cannot be of the type
+var signatureTypes = map[string]bool{
+	"image/x-icon":                  true,
+	"image/bmp":                     true,
+	"image/gif":                     true,
+	"image/webp":                    true,
+	"image/png":                     true,
+	"image/jpeg":                    true,
+	"audio/basic":                   true,
+	"audio/aiff":                    true,
+	"audio/mpeg":                    true,
+	"application/ogg":               true,
+	"audio/midi":                    true,
+	"video/avi":                     true,
+	"audio/wave":                    true,
+	"video/mp4":                     true,
+	"video/webm":                    true,
+	"font/ttf":                      true,
+	"font/otf":                      true,
+	"font/collection":               true,
+	"font/woff":                     true,
+	"font/woff2":                    true,
+	"application/x-gzip":            true,
+	"application/zip":               true,
+	"application/x-rar-compressed":  true,
+	"application/wasm":              true,
+	"application/pdf":               true,
+	"application/postscript":        true,
+	"application/vnd.ms-fontobject": true,
+}
+
+// typeAliases maps alternative names of signature types to the name SniffContentType returns
+var typeAliases = map[string]string{
+	"application/gzip":         "application/x-gzip",
+	"audio/mp3":                "audio/mpeg",
+	"audio/wav":                "audio/wave",
+	"audio/x-wav":              "audio/wave",
+	"image/jpg":                "image/jpeg",
+	"image/vnd.microsoft.icon": "image/x-icon",
+}
+
+// SniffContentType detects the content type from the content using the WHATWG
+// MIME sniffing algorithm, and also detects JSON and SVG. Returns the type
+// without parameters, or ContentTypeUnknown if it cannot be determined.
+func SniffContentType(content []byte) string {
+	sniffed := mediaType(http.DetectContentType(content))
+	switch sniffed {
+	case "text/plain", "text/xml":
+		trimmed := bytes.TrimSpace(content)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+			return "application/json"
+		}
+		if isSVG(trimmed) {
+			return "image/svg+xml"
+		}
+	}
+	return sniffed
+}
+
+// isSVG reports whether text starts with an svg element, after any XML
+// declaration, comments or doctype
+func isSVG(text []byte) bool {
+	for len(text) > 0 {
+		switch {
+		case bytes.HasPrefix(text, []byte("<svg")):
+			return true
+		case bytes.HasPrefix(text, []byte("<!--")):
+			end := bytes.Index(text, []byte("-->"))
+			if end < 0 {
+				return false
+			}
+			text = bytes.TrimSpace(text[end+3:])
+		case bytes.HasPrefix(text, []byte("<?")), bytes.HasPrefix(text, []byte("<!")):
+			end := bytes.IndexByte(text, '>')
+			if end < 0 {
+				return false
+			}
+			text = bytes.TrimSpace(text[end+1:])
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// NormalizeContentType returns the content type with the type and parameter
+// names in lower case, parameters sorted and quoted only where needed, so
+// equivalent types compare equal.
+//
+//	NormalizeContentType(`Text/HTML ; Charset="utf-8"`) // text/html; charset=utf-8
+func NormalizeContentType(contentType string) (string, error) {
+	t, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %v", ErrInvalidContentType, contentType, err)
+	}
+	if !strings.Contains(t, "/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidContentType, contentType)
+	}
+	if charset, ok := params["charset"]; ok {
+		params["charset"] = strings.ToLower(charset)
+	}
+	normalized := mime.FormatMediaType(t, params)
+	if normalized == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidContentType, contentType)
+	}
+	return normalized, nil
+}
+
+// ValidateType checks the declared Type is a valid content type consistent with
+// the content, detecting inscriptions that declare a harmless type for content
+// such as HTML. Content with an encoding is checked after decompressing it with
+// opts. A file without a type is valid, as it declares nothing. Returns
+// ErrContentTypeMismatch if the content is not of the declared type.
+func (f *File) ValidateType(opts ...DecodeOption) error {
+	if f.Type == "" {
+		return nil
+	}
+	normalized, err := NormalizeContentType(f.Type)
+	if err != nil {
+		return err
+	}
+	content, err := f.DecodedContent(opts...)
+	if err != nil {
+		return err
+	}
+
+	declared := mediaType(normalized)
+	if alias, ok := typeAliases[declared]; ok {
+		declared = alias
+	}
+	sniffed := SniffContentType(content)
+	if !typeMatches(declared, sniffed) {
+		return fmt.Errorf("%w: declared %s, detected %s", ErrContentTypeMismatch, declared, sniffed)
+	}
+	return nil
+}
+
+// typeMatches reports whether content sniffed as sniffed can be of the declared type
+func typeMatches(declared, sniffed string) bool {
+	switch {
+	case declared == sniffed:
+		return true
+	case sniffed == "text/html":
+		// Only documents declared as HTML may be rendered as HTML
+		return declared == "application/xhtml+xml"
+	case sniffed == ContentTypeUnknown, isText(sniffed):
+		// Any type without a signature may be text or unrecognised binary
+		return !signatureTypes[declared]
+	}
+	// Content with a signature is only that type, or unspecified binary
+	return declared == ContentTypeUnknown
+}
+
+// isText reports whether a sniffed type is textual
+func isText(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasSuffix(contentType, "+xml") ||
+		contentType == "application/json"
+}
+
+// mediaType returns a content type without parameters
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,112 @@
+package inscription
+
+import (
+	"errors"
+	"testing"
+)
+
+var pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		content []byte
+		want    string
+	}{
+		{pngContent, "image/png"},
+		{[]byte("<!DOCTYPE html><html><body>hi</body></html>"), "text/html"},
+		{[]byte("hello world"), "text/plain"},
+		{[]byte(` {"p":"bsv-20","op":"deploy+mint"}`), "application/json"},
+		{[]byte(`<?xml version="1.0"?><!-- logo --><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+		{[]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+		{[]byte(`<?xml version="1.0"?><note></note>`), "text/xml"},
+		{[]byte{0x00, 0x01, 0x02, 0xff}, ContentTypeUnknown},
+	}
+	for _, tt := range tests {
+		if got := SniffContentType(tt.content); got != tt.want {
+			t.Errorf("SniffContentType(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"text/plain", "text/plain"},
+		{`Text/HTML ; Charset="UTF-8"`, "text/html; charset=utf-8"},
+		{"application/json;b=2;a=1", "application/json; a=1; b=2"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeContentType(tt.contentType)
+		if err != nil {
+			t.Errorf("NormalizeContentType(%q) error: %v", tt.contentType, err)
+		} else if got != tt.want {
+			t.Errorf("NormalizeContentType(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+	for _, invalid := range []string{"", "text", "text/plain; charset"} {
+		if _, err := NormalizeContentType(invalid); !errors.Is(err, ErrInvalidContentType) {
+			t.Errorf("NormalizeContentType(%q) expected ErrInvalidContentType, got %v", invalid, err)
+		}
+	}
+}
+
+func TestFile_ValidateType(t *testing.T) {
+	html := []byte("<html><script>alert(1)</script></html>")
+	tests := []struct {
+		name    string
+		file    File
+		wantErr error
+	}{
+		{"png", File{Type: "image/png", Content: pngContent}, nil},
+		{"png with parameters", File{Type: "IMAGE/PNG; foo=bar", Content: pngContent}, nil},
+		{"html", File{Type: "text/html;charset=utf-8", Content: html}, nil},
+		{"json", File{Type: "application/bsv-20", Content: []byte(`{"p":"bsv-20"}`)}, nil},
+		{"svg as text", File{Type: "text/plain", Content: []byte("<svg></svg>")}, nil},
+		{"no type", File{Content: html}, nil},
+		{"binary", File{Type: ContentTypeUnknown, Content: pngContent}, nil},
+		{"alias", File{Type: "image/jpg", Content: []byte("\xff\xd8\xff\xe0")}, nil},
+		{"html as png", File{Type: "image/png", Content: html}, ErrContentTypeMismatch},
+		{"html as text", File{Type: "text/plain", Content: html}, ErrContentTypeMismatch},
+		{"html as svg", File{Type: "image/svg+xml", Content: html}, ErrContentTypeMismatch},
+		{"png as gif", File{Type: "image/gif", Content: pngContent}, ErrContentTypeMismatch},
+		{"png as text", File{Type: "text/plain", Content: pngContent}, ErrContentTypeMismatch},
+		{"text as png", File{Type: "image/png", Content: []byte("not a png")}, ErrContentTypeMismatch},
+		{"invalid type", File{Type: "image", Content: pngContent}, ErrInvalidContentType},
+		{"unsupported encoding", File{Type: "text/html", Encoding: "zstd", Content: html}, ErrUnsupportedEncoding},
+	}
+	for _, tt := range tests {
+		if err := tt.file.ValidateType(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: ValidateType() = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Encoded content is checked after decompressing
+	f, err := NewFile("image/png", EncodingGzip, html)
+	if err != nil {
+		t.Fatalf("NewFile error: %v", err)
+	}
+	if err := f.ValidateType(); !errors.Is(err, ErrContentTypeMismatch) {
+		t.Errorf("expected ErrContentTypeMismatch for compressed HTML, got %v", err)
+	}
+}
+
+func TestLock_EmptyType(t *testing.T) {
+	locked, err := (&Inscription{File: File{Content: []byte("untyped")}}).Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	decoded := Decode(locked)
+	if decoded == nil {
+		t.Fatalf("Decode failed, got nil")
+	}
+	for _, entry := range decoded.envelope.entries {
+		if entry.tag == TagContentType {
+			t.Errorf("expected no content type field, got %x", entry.raw)
+		}
+	}
+	if string(decoded.File.Content) != "untyped" {
+		t.Errorf("File.Content mismatch: got %q", decoded.File.Content)
+	}
+}
@@ -0,0 +1,172 @@
+package inscription
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content encodings, as used in the content-encoding field
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+	EncodingBrotli   = "br"
+)
+
+// DefaultMaxDecodedSize limits the size of decompressed content unless
+// WithMaxDecodedSize is given, so a small compressed inscription cannot expand
+// to exhaust memory
+const DefaultMaxDecodedSize = 32 << 20
+
+var (
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	ErrDecodedTooLarge     = errors.New("decompressed content too large")
+)
+
+// DecodeOption configures how a content encoding is removed
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	maxDecodedSize int64
+}
+
+// WithMaxDecodedSize limits decompressed content to size bytes in place of
+// DefaultMaxDecodedSize
+func WithMaxDecodedSize(size int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxDecodedSize = size
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) *decodeOptions {
+	o := &decodeOptions{maxDecodedSize: DefaultMaxDecodedSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Compress encodes data with a content encoding. An empty encoding or
+// identity returns data unchanged.
+func Compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "", EncodingIdentity:
+		return data, nil
+	case EncodingGzip:
+		w, _ = gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	case EncodingBrotli:
+		w = brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress removes a content encoding from data. An empty encoding or
+// identity returns data unchanged. Returns ErrDecodedTooLarge if the content
+// decompresses to more than DefaultMaxDecodedSize, or the size given with
+// WithMaxDecodedSize.
+func Decompress(encoding string, data []byte, opts ...DecodeOption) ([]byte, error) {
+	if encoding == "" || encoding == EncodingIdentity {
+		return data, nil
+	}
+	r, err := decompressReader(encoding, bytes.NewReader(data), newDecodeOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// decompressReader returns a reader removing a content encoding from r, which
+// fails with ErrDecodedTooLarge once it reads past the size limit
+func decompressReader(encoding string, r io.Reader, o *decodeOptions) (io.ReadCloser, error) {
+	var decoded io.ReadCloser
+	switch encoding {
+	case "", EncodingIdentity:
+		return io.NopCloser(r), nil
+	case EncodingGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		decoded = gz
+	case EncodingBrotli:
+		decoded = io.NopCloser(brotli.NewReader(r))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+	return &limitedReader{ReadCloser: decoded, remaining: o.maxDecodedSize}, nil
+}
+
+// limitedReader reads up to remaining bytes, then fails with ErrDecodedTooLarge
+// if there is more to read
+type limitedReader struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// Read a byte past the limit to tell content of exactly the limit from larger content
+		var b [1]byte
+		n, err := r.ReadCloser.Read(b[:])
+		if n > 0 {
+			return 0, ErrDecodedTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// DecodedContent returns Content with its encoding removed, or Decoded if it is
+// set. Content is decompressed on each call, limited to DefaultMaxDecodedSize
+// unless WithMaxDecodedSize is given.
+func (f *File) DecodedContent(opts ...DecodeOption) ([]byte, error) {
+	if f.Decoded != nil {
+		return f.Decoded, nil
+	}
+	return Decompress(f.Encoding, f.Content, opts...)
+}
+
+// NewFile returns a file of the given type whose content is compressed with
+// encoding. Size and Hash describe the compressed content, as inscribed.
+func NewFile(contentType, encoding string, content []byte) (*File, error) {
+	compressed, err := Compress(encoding, content)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{
+		Type:     contentType,
+		Encoding: encoding,
+		Content:  compressed,
+		Decoded:  content,
+	}
+	f.setHash()
+	return f, nil
+}
+
+// setHash sets Size and Hash from the inscribed content
+func (f *File) setHash() {
+	f.Size = uint32(len(f.Content))
+	hash := sha256.Sum256(f.Content)
+	f.Hash = hash[:]
+}
@@ -0,0 +1,118 @@
+package inscription
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestRoundTrip_ContentEncoding(t *testing.T) {
+	content := bytes.Repeat([]byte(`{"name":"inscription","traits":[1,2,3]}`), 100)
+	for _, encoding := range []string{EncodingGzip, EncodingBrotli} {
+		t.Run(encoding, func(t *testing.T) {
+			insc := &Inscription{
+				File: File{Type: "application/json", Encoding: encoding, Decoded: content},
+			}
+			locked, err := insc.Lock()
+			if err != nil {
+				t.Fatalf("Lock error: %v", err)
+			}
+			if len(*locked) >= len(content) {
+				t.Errorf("expected compressed script, got %d bytes for %d bytes of content", len(*locked), len(content))
+			}
+
+			decoded := Decode(locked)
+			if decoded == nil {
+				t.Fatalf("Decode failed, got nil")
+			}
+			if decoded.File.Encoding != encoding {
+				t.Errorf("File.Encoding mismatch: got %q, want %q", decoded.File.Encoding, encoding)
+			}
+			if decoded.File.Decoded != nil {
+				t.Errorf("Decode should not decompress the content")
+			}
+			if got, err := decoded.File.DecodedContent(); err != nil || !bytes.Equal(got, content) {
+				t.Errorf("DecodedContent mismatch: got %d bytes, want %d, %v", len(got), len(content), err)
+			}
+			// Size and Hash describe the inscribed bytes
+			hash := sha256.Sum256(decoded.File.Content)
+			if !bytes.Equal(decoded.File.Hash, hash[:]) || decoded.File.Size != uint32(len(decoded.File.Content)) {
+				t.Errorf("File.Hash and Size should be over the compressed content")
+			}
+
+			relocked, err := decoded.Lock()
+			if err != nil {
+				t.Fatalf("Lock error: %v", err)
+			}
+			if !bytes.Equal(*relocked, *locked) {
+				t.Errorf("Decode then Lock should reproduce the script")
+			}
+		})
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	content := []byte("<html><body>hello hello hello hello</body></html>")
+	f, err := NewFile("text/html", EncodingGzip, content)
+	if err != nil {
+		t.Fatalf("NewFile error: %v", err)
+	}
+	hash := sha256.Sum256(f.Content)
+	if !bytes.Equal(f.Hash, hash[:]) || f.Size != uint32(len(f.Content)) {
+		t.Errorf("File.Hash and Size should be over the compressed content")
+	}
+	insc := &Inscription{File: *f}
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	if decoded := Decode(locked); !bytes.Equal(decoded.File.Hash, f.Hash) {
+		t.Errorf("File.Hash mismatch: got %x, want %x", decoded.File.Hash, f.Hash)
+	}
+
+	if _, err := NewFile("text/html", "zstd", content); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestDecode_ContentEncodingUnsupported(t *testing.T) {
+	insc := &Inscription{
+		File: File{Type: "text/plain", Encoding: "zstd", Content: []byte("not really zstd")},
+	}
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	decoded := Decode(locked)
+	if decoded == nil {
+		t.Fatalf("Decode failed, got nil")
+	}
+	if _, err := decoded.File.DecodedContent(); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("expected ErrUnsupportedEncoding, got %v", err)
+	}
+	if !bytes.Equal(decoded.File.Content, insc.File.Content) {
+		t.Errorf("File.Content mismatch: got %q", decoded.File.Content)
+	}
+}
+
+func TestDecompress_TooLarge(t *testing.T) {
+	compressed, err := Compress(EncodingGzip, make([]byte, 1024))
+	if err != nil {
+		t.Fatalf("Compress error: %v", err)
+	}
+	if _, err := Decompress(EncodingGzip, compressed, WithMaxDecodedSize(1023)); !errors.Is(err, ErrDecodedTooLarge) {
+		t.Errorf("expected ErrDecodedTooLarge, got %v", err)
+	}
+	if decoded, err := Decompress(EncodingGzip, compressed, WithMaxDecodedSize(1024)); err != nil || len(decoded) != 1024 {
+		t.Errorf("expected 1024 bytes, got %d, %v", len(decoded), err)
+	}
+
+	f := &File{Type: "text/plain", Encoding: EncodingGzip, Content: compressed}
+	if _, err := f.DecodedContent(WithMaxDecodedSize(1023)); !errors.Is(err, ErrDecodedTooLarge) {
+		t.Errorf("expected ErrDecodedTooLarge from DecodedContent, got %v", err)
+	}
+	if err := f.ValidateType(WithMaxDecodedSize(1023)); !errors.Is(err, ErrDecodedTooLarge) {
+		t.Errorf("expected ErrDecodedTooLarge from ValidateType, got %v", err)
+	}
+}
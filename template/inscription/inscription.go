@@ -2,25 +2,78 @@ package inscription
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"unicode/utf8"
 
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
 )
 
+var ErrInvalidTag = errors.New("invalid inscription field tag")
+
+// Envelope field tags
+const (
+	TagContent         = 0
+	TagContentType     = 1
+	TagPointer         = 2
+	TagParent          = 3
+	TagMetadata        = 5
+	TagMetaprotocol    = 7
+	TagContentEncoding = 9
+	TagDelegate        = 11
+)
+
+// knownTags are the tags decoded into Inscription fields, in the order Lock writes them
+var knownTags = []int{TagContentType, TagPointer, TagParent, TagMetadata, TagMetaprotocol, TagContentEncoding, TagDelegate}
+
 type File struct {
-	Hash    []byte `json:"hash"`
-	Size    uint32 `json:"size"`
-	Type    string `json:"type"`
-	Content []byte `json:"-"`
+	Hash     []byte `json:"hash"`
+	Size     uint32 `json:"size"`
+	Type     string `json:"type"`
+	Encoding string `json:"encoding,omitempty"` // Content encoding, e.g. gzip
+	Content  []byte `json:"-"`                  // Content as inscribed, which Size and Hash describe
+	// Decoded is the content before its encoding, as passed to NewFile. Decode
+	// leaves it unset; call DecodedContent to decompress Content when needed.
+	// Lock compresses Decoded with Encoding if Content is empty.
+	Decoded []byte `json:"-"`
+}
+
+// Field is an envelope field that is not decoded into an Inscription field.
+// Tag is -1 if the tag push is not a valid tag number.
+type Field struct {
+	Tag   int    `json:"tag"`
+	Value []byte `json:"value"`
 }
 
 type Inscription struct {
 	File         File                  `json:"file,omitempty"`
 	Parent       *transaction.Outpoint `json:"parent,omitempty"`
+	Pointer      *uint64               `json:"pointer,omitempty"`
+	Metadata     []byte                `json:"metadata,omitempty"` // CBOR encoded
+	Metaprotocol string                `json:"metaprotocol,omitempty"`
+	Delegate     *transaction.Outpoint `json:"delegate,omitempty"`
+	Fields       []*Field              `json:"fields,omitempty"` // Unrecognised fields, in envelope order
 	ScriptPrefix []byte                `json:"prefix,omitempty"`
 	ScriptSuffix []byte                `json:"suffix,omitempty"`
+
+	// envelope records the decoded field layout so Lock can reproduce it
+	envelope *envelope
+}
+
+// envelope is the layout of a decoded envelope. Fields whose value has not
+// changed since decoding are written back with their original bytes.
+type envelope struct {
+	entries []*envelopeEntry
+	values  map[int][]byte // Value of each known tag when decoded, absent if not set
+}
+
+type envelopeEntry struct {
+	tag    int
+	raw    []byte // Script bytes of the tag and value pushes
+	tagLen int    // Length of the tag push within raw
+	value  []byte // Value as decoded
+	field  *Field // Set for unrecognised fields
 }
 
 func Decode(scr *script.Script) *Inscription {
@@ -31,66 +84,315 @@ func Decode(scr *script.Script) *Inscription {
 		} else if pos >= 2 && op.Op == script.OpDATA3 && bytes.Equal(op.Data, []byte("ord")) && (*scr)[startI-2] == 0 && (*scr)[startI-1] == script.OpIF {
 			insc := &Inscription{
 				ScriptPrefix: (*scr)[:startI-2],
+				envelope:     &envelope{},
 			}
 
 		ordLoop:
 			for {
-				var field int
-				var err error
+				fieldStart := pos
 				var op, op2 *script.ScriptChunk
+				var err error
 				if op, err = scr.ReadOp(&pos); err != nil || op.Op > script.Op16 {
-					return insc
-				} else if op2, err = scr.ReadOp(&pos); err != nil || op2.Op > script.Op16 {
-					return insc
-				} else if op.Op > script.OpPUSHDATA4 && op.Op <= script.Op16 {
-					field = int(op.Op) - 80
-				} else if len(op.Data) == 1 {
-					field = int(op.Data[0])
-				} else if len(op.Data) > 1 {
-					continue
+					if err == nil && op.Op == script.OpENDIF {
+						// An envelope without content, such as a delegate
+						insc.ScriptSuffix = (*scr)[pos:]
+					}
+					return insc.decoded()
 				}
-				switch field {
-				case 0:
+				tagLen := pos - fieldStart
+				if op2, err = scr.ReadOp(&pos); err != nil || op2.Op > script.Op16 {
+					return insc.decoded()
+				}
+				entry := &envelopeEntry{
+					tag:    parseTag(op),
+					raw:    (*scr)[fieldStart:pos],
+					tagLen: tagLen,
+					value:  op2.Data,
+				}
+				if entry.tag == TagContent {
+					insc.envelope.entries = append(insc.envelope.entries, entry)
 					insc.File.Content = op2.Data
-					insc.File.Size = uint32(len(insc.File.Content))
-					hash := sha256.Sum256(insc.File.Content)
-					insc.File.Hash = hash[:]
+					insc.File.setHash()
 					break ordLoop
-				case 1:
-					if len(op2.Data) < 256 && utf8.Valid(op2.Data) {
-						insc.File.Type = string(op2.Data)
-					}
-				case 3:
-					if len(op2.Data) == 36 {
-						insc.Parent = transaction.NewOutpointFromBytes([36]byte(op2.Data))
-					}
 				}
-
+				insc.addEntry(entry)
 			}
 			op, err := scr.ReadOp(&pos)
 			if err != nil || op.Op == script.OpENDIF {
 				insc.ScriptSuffix = (*scr)[pos:]
-				return insc
+				return insc.decoded()
 			}
 		}
 	}
 	return nil
 }
 
+// parseTag returns the tag number of a tag push, or -1 if it is not a valid tag.
+// Tags may be pushed as OP_0 to OP_16 or as little-endian numbers.
+func parseTag(op *script.ScriptChunk) int {
+	switch {
+	case op.Op == script.Op0:
+		return TagContent
+	case op.Op >= script.Op1 && op.Op <= script.Op16:
+		return int(op.Op-script.Op1) + 1
+	case op.Op <= script.OpPUSHDATA4 && len(op.Data) <= 4:
+		var tag [8]byte
+		copy(tag[:], op.Data)
+		return int(binary.LittleEndian.Uint64(tag[:]))
+	}
+	return -1
+}
+
+// addEntry records a decoded field other than content
+func (i *Inscription) addEntry(entry *envelopeEntry) {
+	i.envelope.entries = append(i.envelope.entries, entry)
+	if !i.setField(entry.tag, entry.value) {
+		entry.field = &Field{Tag: entry.tag, Value: entry.value}
+		i.Fields = append(i.Fields, entry.field)
+	}
+}
+
+// setField sets the Inscription field for a known tag and reports whether the
+// value was recognised. Metadata pushed in several chunks is concatenated; for
+// other tags the first occurrence wins.
+func (i *Inscription) setField(tag int, value []byte) bool {
+	switch tag {
+	case TagContentType:
+		if i.File.Type == "" && len(value) < 256 && utf8.Valid(value) {
+			i.File.Type = string(value)
+		}
+	case TagPointer:
+		if i.Pointer == nil && len(value) <= 8 {
+			var pointer [8]byte
+			copy(pointer[:], value)
+			p := binary.LittleEndian.Uint64(pointer[:])
+			i.Pointer = &p
+		}
+	case TagParent:
+		if i.Parent == nil && len(value) == 36 {
+			i.Parent = transaction.NewOutpointFromBytes([36]byte(value))
+		}
+	case TagMetadata:
+		i.Metadata = append(i.Metadata, value...)
+	case TagMetaprotocol:
+		if i.Metaprotocol == "" && utf8.Valid(value) {
+			i.Metaprotocol = string(value)
+		}
+	case TagContentEncoding:
+		if i.File.Encoding == "" && utf8.Valid(value) {
+			i.File.Encoding = string(value)
+		}
+	case TagDelegate:
+		if i.Delegate == nil && len(value) == 36 {
+			i.Delegate = transaction.NewOutpointFromBytes([36]byte(value))
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// decoded records the decoded value of each known field so Lock can tell which changed
+func (i *Inscription) decoded() *Inscription {
+	i.envelope.values = make(map[int][]byte)
+	for _, tag := range knownTags {
+		if value, ok := i.fieldValue(tag); ok {
+			i.envelope.values[tag] = value
+		}
+	}
+	return i
+}
+
+// fieldValue returns the envelope value of a known tag and whether it is set
+func (i *Inscription) fieldValue(tag int) ([]byte, bool) {
+	switch tag {
+	case TagContentType:
+		return []byte(i.File.Type), i.File.Type != ""
+	case TagPointer:
+		if i.Pointer == nil {
+			return nil, false
+		}
+		// Little-endian with trailing zero bytes removed
+		return bytes.TrimRight(binary.LittleEndian.AppendUint64(nil, *i.Pointer), "\x00"), true
+	case TagParent:
+		if i.Parent == nil {
+			return nil, false
+		}
+		return i.Parent.Bytes(), true
+	case TagMetadata:
+		return i.Metadata, len(i.Metadata) > 0
+	case TagMetaprotocol:
+		return []byte(i.Metaprotocol), i.Metaprotocol != ""
+	case TagContentEncoding:
+		return []byte(i.File.Encoding), i.File.Encoding != ""
+	case TagDelegate:
+		if i.Delegate == nil {
+			return nil, false
+		}
+		return i.Delegate.Bytes(), true
+	}
+	return nil, false
+}
+
+// unchanged reports whether a known field still has the value it was decoded with
+func (e *envelope) unchanged(i *Inscription, tag int) bool {
+	value, ok := i.fieldValue(tag)
+	original, wasSet := e.values[tag]
+	return ok == wasSet && bytes.Equal(value, original)
+}
+
 func (i *Inscription) Lock() (*script.Script, error) {
-	s := script.NewFromBytes(i.ScriptPrefix)
+	header, err := i.header()
+	if err != nil {
+		return nil, err
+	}
+	fileContent, err := i.fileContent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Allocate the script once, as content may be large
+	s := make(script.Script, 0, len(i.ScriptPrefix)+len(header)+len(fileContent)+7+len(i.ScriptSuffix))
+	s = append(s, i.ScriptPrefix...)
+	s = append(s, header...)
+
+	// Add content, which decoded envelopes without content leave out while empty
+	content := i.envelope.content()
+	switch {
+	case content != nil && bytes.Equal(content.value, fileContent):
+		s = append(s, content.raw...)
+	case content != nil || i.envelope == nil || len(fileContent) > 0:
+		prefix, err := pushPrefix(int64(len(fileContent)))
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, script.Op0)
+		s = append(s, prefix...)
+		s = append(s, fileContent...)
+	}
+
+	s = append(s, script.OpENDIF)
+	s = append(s, i.ScriptSuffix...)
+	return &s, nil
+}
+
+// header returns the start of the envelope up to the content: OP_FALSE OP_IF "ord"
+// and the fields
+func (i *Inscription) header() (script.Script, error) {
+	s := &script.Script{}
 	_ = s.AppendOpcodes(script.Op0, script.OpIF)
 	_ = s.AppendPushData([]byte("ord"))
 
-	// Add file type if available
-	// if i.File.Type != "" {
-	_ = s.AppendOpcodes(script.Op1)
-	_ = s.AppendPushDataString(i.File.Type)
+	written := make(map[int]bool)
+	fields := make(map[*Field]bool)
+
+	// Fields of a decoded envelope keep their position, and their original
+	// bytes if unchanged
+	if i.envelope != nil {
+		for _, entry := range i.envelope.entries {
+			switch {
+			case entry.tag == TagContent:
+			case entry.field != nil:
+				if !i.hasField(entry.field) {
+					continue
+				}
+				fields[entry.field] = true
+				if entry.field.Tag == entry.tag && bytes.Equal(entry.field.Value, entry.value) {
+					*s = append(*s, entry.raw...)
+					continue
+				}
+				if entry.field.Tag == entry.tag {
+					// Keep the original tag push, which may not be a valid tag number
+					*s = append(*s, entry.raw[:entry.tagLen]...)
+					if err := s.AppendPushData(entry.field.Value); err != nil {
+						return nil, err
+					}
+				} else if err := appendField(s, entry.field.Tag, entry.field.Value); err != nil {
+					return nil, err
+				}
+			case i.envelope.unchanged(i, entry.tag):
+				*s = append(*s, entry.raw...)
+				written[entry.tag] = true
+			case !written[entry.tag]:
+				if err := i.appendField(s, entry.tag); err != nil {
+					return nil, err
+				}
+				written[entry.tag] = true
+			}
+		}
+	}
+
+	for _, tag := range knownTags {
+		if !written[tag] {
+			if err := i.appendField(s, tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, f := range i.Fields {
+		if !fields[f] {
+			if err := appendField(s, f.Tag, f.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return *s, nil
+}
+
+// fileContent returns the content to inscribe, compressing File.Decoded if
+// File.Content is empty
+func (i *Inscription) fileContent() ([]byte, error) {
+	if len(i.File.Content) == 0 && len(i.File.Decoded) > 0 {
+		return Compress(i.File.Encoding, i.File.Decoded)
+	}
+	return i.File.Content, nil
+}
 
-	// Add content
-	_ = s.AppendOpcodes(script.Op0)
-	_ = s.AppendPushData(i.File.Content)
+// content returns the content entry of a decoded envelope, or nil if there was none
+func (e *envelope) content() *envelopeEntry {
+	if e == nil {
+		return nil
+	}
+	for _, entry := range e.entries {
+		if entry.tag == TagContent {
+			return entry
+		}
+	}
+	return nil
+}
+
+// hasField reports whether f is still one of the inscription's unrecognised fields
+func (i *Inscription) hasField(f *Field) bool {
+	for _, field := range i.Fields {
+		if field == f {
+			return true
+		}
+	}
+	return false
+}
 
-	_ = s.AppendOpcodes(script.OpENDIF)
-	return script.NewFromBytes(append(*s, i.ScriptSuffix...)), nil
+// appendField appends a known field to s if it is set
+func (i *Inscription) appendField(s *script.Script, tag int) error {
+	value, ok := i.fieldValue(tag)
+	if !ok {
+		return nil
+	}
+	return appendField(s, tag, value)
+}
+
+// appendField appends a tag and value, using OP_1 to OP_16 for small tags
+func appendField(s *script.Script, tag int, value []byte) error {
+	switch {
+	case tag >= 1 && tag <= 16:
+		if err := s.AppendOpcodes(script.Op1 + byte(tag-1)); err != nil {
+			return err
+		}
+	case tag >= 0:
+		if err := s.AppendPushData(bytes.TrimRight(binary.LittleEndian.AppendUint32(nil, uint32(tag)), "\x00")); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidTag
+	}
+	return s.AppendPushData(value)
 }
@@ -1,9 +1,12 @@
 package inscription
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 )
 
 func TestDecode_InvalidScript(t *testing.T) {
@@ -52,3 +55,177 @@ func TestRoundTrip_LockDecode(t *testing.T) {
 		t.Errorf("File.Content mismatch: got %q, want %q", string(decoded.File.Content), string(insc.File.Content))
 	}
 }
+
+// envelopeScript builds an envelope from raw field bytes following "ord"
+func envelopeScript(t *testing.T, fields ...[]byte) *script.Script {
+	t.Helper()
+	s := &script.Script{}
+	_ = s.AppendOpcodes(script.Op0, script.OpIF)
+	_ = s.AppendPushData([]byte("ord"))
+	for _, f := range fields {
+		*s = append(*s, f...)
+	}
+	_ = s.AppendOpcodes(script.OpENDIF)
+	return s
+}
+
+func TestDecode_AllFields(t *testing.T) {
+	parent := bytes.Repeat([]byte{0x01}, 36)
+	delegate := bytes.Repeat([]byte{0x02}, 36)
+	s := envelopeScript(t,
+		[]byte{script.Op1, 0x0a}, []byte("text/plain"),
+		[]byte{script.Op2, 0x02, 0x10, 0x27},
+		append([]byte{script.Op3, 0x24}, parent...),
+		[]byte{script.Op5, 0x02, 0xa1, 0x01},
+		[]byte{script.Op5, 0x01, 0x02},
+		[]byte{script.Op7, 0x04}, []byte("brc0"),
+		[]byte{script.Op9, 0x04}, []byte("gzip"),
+		append([]byte{script.Op11, 0x24}, delegate...),
+		[]byte{0x01, 0x21, 0x01, 0x07}, // Unknown tag 33 pushed as data
+		[]byte{script.Op0, 0x05}, []byte("hello"),
+	)
+
+	insc := Decode(s)
+	if insc == nil {
+		t.Fatalf("Decode failed, got nil")
+	}
+	if insc.File.Type != "text/plain" || insc.File.Encoding != "gzip" || string(insc.File.Content) != "hello" {
+		t.Errorf("File mismatch: got %+v", insc.File)
+	}
+	if insc.Pointer == nil || *insc.Pointer != 10000 {
+		t.Errorf("Pointer mismatch: got %v", insc.Pointer)
+	}
+	if insc.Parent == nil || !bytes.Equal(insc.Parent.Bytes(), parent) {
+		t.Errorf("Parent mismatch: got %v", insc.Parent)
+	}
+	if !bytes.Equal(insc.Metadata, []byte{0xa1, 0x01, 0x02}) {
+		t.Errorf("Metadata chunks should be concatenated: got %x", insc.Metadata)
+	}
+	if insc.Metaprotocol != "brc0" {
+		t.Errorf("Metaprotocol mismatch: got %q", insc.Metaprotocol)
+	}
+	if insc.Delegate == nil || !bytes.Equal(insc.Delegate.Bytes(), delegate) {
+		t.Errorf("Delegate mismatch: got %v", insc.Delegate)
+	}
+	if len(insc.Fields) != 1 || insc.Fields[0].Tag != 33 || !bytes.Equal(insc.Fields[0].Value, []byte{0x07}) {
+		t.Errorf("Unknown fields mismatch: got %+v", insc.Fields)
+	}
+
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	if !bytes.Equal(*locked, *s) {
+		t.Errorf("Decode then Lock should reproduce the script:\ngot  %x\nwant %x", *locked, *s)
+	}
+}
+
+func TestRoundTrip_NonCanonical(t *testing.T) {
+	// Non-minimal pushes, an unknown tag and an invalid tag push are kept as is
+	s := script.NewFromBytes([]byte{0x76}) // Prefix
+	*s = append(*s, *envelopeScript(t,
+		[]byte{0x01, 0x01, script.OpPUSHDATA1, 0x03}, []byte("a/b"),
+		[]byte{script.Op15, 0x01, 0xff},
+		[]byte{0x05, 1, 2, 3, 4, 5, 0x00}, // Tag longer than 4 bytes
+		[]byte{script.Op0, script.OpPUSHDATA2, 0x02, 0x00}, []byte("hi"),
+	)...)
+	*s = append(*s, script.OpDROP)
+
+	insc := Decode(s)
+	if insc == nil {
+		t.Fatalf("Decode failed, got nil")
+	}
+	if len(insc.Fields) != 2 || insc.Fields[0].Tag != 15 || insc.Fields[1].Tag != -1 {
+		t.Fatalf("Unknown fields mismatch: got %+v", insc.Fields)
+	}
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	if !bytes.Equal(*locked, *s) {
+		t.Errorf("Decode then Lock should reproduce the script:\ngot  %x\nwant %x", *locked, *s)
+	}
+
+	// Changing a field re-encodes only that field
+	insc.Fields[1].Value = []byte{0x08}
+	locked, err = insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	want := script.NewFromBytes([]byte{0x76})
+	*want = append(*want, *envelopeScript(t,
+		[]byte{0x01, 0x01, script.OpPUSHDATA1, 0x03}, []byte("a/b"),
+		[]byte{script.Op15, 0x01, 0xff},
+		[]byte{0x05, 1, 2, 3, 4, 5, 0x01, 0x08},
+		[]byte{script.Op0, script.OpPUSHDATA2, 0x02, 0x00}, []byte("hi"),
+	)...)
+	*want = append(*want, script.OpDROP)
+	if !bytes.Equal(*locked, *want) {
+		t.Errorf("Only the changed field should be re-encoded:\ngot  %x\nwant %x", *locked, *want)
+	}
+}
+
+func TestRoundTrip_Delegate(t *testing.T) {
+	// Delegate inscriptions have no content
+	s := envelopeScript(t, append([]byte{script.Op11, 0x24}, bytes.Repeat([]byte{0x03}, 36)...))
+	insc := Decode(s)
+	if insc == nil || insc.Delegate == nil {
+		t.Fatalf("Decode failed, got %+v", insc)
+	}
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	if !bytes.Equal(*locked, *s) {
+		t.Errorf("Decode then Lock should reproduce the script:\ngot  %x\nwant %x", *locked, *s)
+	}
+}
+
+func TestLock_Fields(t *testing.T) {
+	txid, _ := chainhash.NewHashFromHex("9cf1f18ea9b6cf8e0ab0dbd31e23f4ad2f1a9a7ba5ec5fa0b4fd52ebc72ed2f1")
+	pointer := uint64(256)
+	insc := &Inscription{
+		File:         File{Type: "text/plain", Content: []byte("child")},
+		Parent:       &transaction.Outpoint{Txid: *txid, Index: 2},
+		Pointer:      &pointer,
+		Metadata:     []byte{0xa0},
+		Metaprotocol: "test",
+		Fields:       []*Field{{Tag: 255, Value: []byte("x")}},
+	}
+	locked, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	decoded := Decode(locked)
+	if decoded == nil {
+		t.Fatalf("Decode failed, got nil")
+	}
+	if decoded.Parent == nil || decoded.Parent.String() != insc.Parent.String() {
+		t.Errorf("Parent mismatch: got %v, want %v", decoded.Parent, insc.Parent)
+	}
+	if decoded.Pointer == nil || *decoded.Pointer != pointer {
+		t.Errorf("Pointer mismatch: got %v", decoded.Pointer)
+	}
+	if !bytes.Equal(decoded.Metadata, insc.Metadata) || decoded.Metaprotocol != insc.Metaprotocol {
+		t.Errorf("Metadata mismatch: got %x %q", decoded.Metadata, decoded.Metaprotocol)
+	}
+	if len(decoded.Fields) != 1 || decoded.Fields[0].Tag != 255 || string(decoded.Fields[0].Value) != "x" {
+		t.Errorf("Fields mismatch: got %+v", decoded.Fields)
+	}
+
+	// Changing a decoded field re-encodes it
+	decoded.Parent = nil
+	decoded.File.Type = "text/html"
+	relocked, err := decoded.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	again := Decode(relocked)
+	if again.Parent != nil || again.File.Type != "text/html" || *again.Pointer != pointer {
+		t.Errorf("Changed fields not re-encoded: got %+v", again)
+	}
+
+	if _, err := (&Inscription{Fields: []*Field{{Tag: -1}}}).Lock(); err != ErrInvalidTag {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package inscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bitcoin-sv/go-templates/lib"
+	"github.com/bitcoin-sv/go-templates/template/bitcom"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// DefaultMaxTraceDepth is the number of transfers an OriginTracker follows by default
+const DefaultMaxTraceDepth = 10000
+
+var (
+	ErrNotOrdinal       = errors.New("output is not a 1 satoshi ordinal")
+	ErrOutputNotFound   = errors.New("output not found")
+	ErrTraceTooDeep     = errors.New("ordinal trace exceeded maximum depth")
+	ErrInputNotResolved = errors.New("input satoshis not available")
+)
+
+// Origin is where an ordinal was inscribed, and its history since
+type Origin struct {
+	Outpoint    *transaction.Outpoint   `json:"outpoint"`           // Output the ordinal was first inscribed in
+	Inscription *Inscription            `json:"inscription"`        // The original inscription
+	Metadata    *bitcom.MapState        `json:"metadata,omitempty"` // MAP metadata applied from the origin onwards
+	Provenance  []*transaction.Outpoint `json:"provenance"`         // Outputs holding the ordinal, from the origin to the traced output
+}
+
+// OriginTracker traces 1 satoshi ordinals back through transfers to the output
+// they were inscribed in. A satoshi moves from the inputs to the outputs in
+// order: the satoshi at offset n of the outputs is the satoshi at offset n of
+// the inputs. The trace follows the satoshi while the input holding it is a
+// 1 satoshi output, and the origin is the earliest of those outputs with an
+// inscription; a later inscription on the same satoshi is an update, not a new
+// origin.
+type OriginTracker struct {
+	Source   lib.TxSource
+	MaxDepth int // Transfers to follow, DefaultMaxTraceDepth if 0
+}
+
+// NewOriginTracker creates a tracker loading transactions from src
+func NewOriginTracker(src lib.TxSource) *OriginTracker {
+	return &OriginTracker{Source: src}
+}
+
+// traceStep is an output holding the ordinal
+type traceStep struct {
+	outpoint *transaction.Outpoint
+	output   *transaction.TransactionOutput
+}
+
+// Trace finds the origin of the ordinal held by the 1 satoshi output at outpoint,
+// and the MAP metadata set on it since. Returns ErrNotOrdinal if the output
+// does not hold exactly 1 satoshi, and ErrNoInscription if the satoshi was
+// never inscribed.
+func (t *OriginTracker) Trace(ctx context.Context, outpoint *transaction.Outpoint) (*Origin, error) {
+	maxDepth := t.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxTraceDepth
+	}
+
+	// Walk back from the outpoint, newest first
+	var steps []*traceStep
+	for current := outpoint; current != nil; {
+		if len(steps) > maxDepth {
+			return nil, fmt.Errorf("%w: %d", ErrTraceTooDeep, maxDepth)
+		}
+		tx, err := t.loadTx(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if int(current.Index) >= len(tx.Outputs) {
+			return nil, fmt.Errorf("%w: %s", ErrOutputNotFound, current)
+		}
+		output := tx.Outputs[current.Index]
+		if output.Satoshis != 1 {
+			return nil, fmt.Errorf("%w: %s", ErrNotOrdinal, current)
+		}
+		steps = append(steps, &traceStep{outpoint: current, output: output})
+
+		if current, err = t.previous(ctx, tx, current.Index); err != nil {
+			return nil, err
+		}
+	}
+
+	// The origin is the earliest inscribed output
+	origin := -1
+	var insc *Inscription
+	for i := len(steps) - 1; i >= 0; i-- {
+		if insc = Decode(steps[i].output.LockingScript); insc != nil {
+			origin = i
+			break
+		}
+	}
+	if origin < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInscription, outpoint)
+	}
+
+	result := &Origin{
+		Outpoint:    steps[origin].outpoint,
+		Inscription: insc,
+		Metadata:    bitcom.NewMapState(),
+	}
+	for i := origin; i >= 0; i-- {
+		result.Provenance = append(result.Provenance, steps[i].outpoint)
+		for _, m := range bitcom.Values[*bitcom.Map](bitcom.DecodeAll(steps[i].output.LockingScript)) {
+			result.Metadata.Apply(m)
+		}
+	}
+	return result, nil
+}
+
+// previous returns the 1 satoshi output spent by tx that held the satoshi now
+// in output vout, or nil if the satoshi came from a larger output, fees or a coinbase
+func (t *OriginTracker) previous(ctx context.Context, tx *transaction.Transaction, vout uint32) (*transaction.Outpoint, error) {
+	if tx.IsCoinbase() {
+		return nil, nil
+	}
+	var offset uint64
+	for _, output := range tx.Outputs[:vout] {
+		offset += output.Satoshis
+	}
+
+	var inputOffset uint64
+	for _, input := range tx.Inputs {
+		satoshis, err := t.inputSatoshis(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if offset < inputOffset+satoshis {
+			if satoshis != 1 {
+				return nil, nil
+			}
+			return &transaction.Outpoint{Txid: *input.SourceTXID, Index: input.SourceTxOutIndex}, nil
+		}
+		inputOffset += satoshis
+	}
+	return nil, nil
+}
+
+// inputSatoshis returns the satoshis spent by input, from its source output if
+// attached to the transaction, or loaded from the source
+func (t *OriginTracker) inputSatoshis(ctx context.Context, input *transaction.TransactionInput) (uint64, error) {
+	if satoshis := input.SourceTxSatoshis(); satoshis != nil {
+		return *satoshis, nil
+	}
+	outpoint := &transaction.Outpoint{Txid: *input.SourceTXID, Index: input.SourceTxOutIndex}
+	tx, err := t.loadTx(ctx, outpoint)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInputNotResolved, outpoint, err)
+	}
+	if int(outpoint.Index) >= len(tx.Outputs) {
+		return 0, fmt.Errorf("%w: %s", ErrOutputNotFound, outpoint)
+	}
+	return tx.Outputs[outpoint.Index].Satoshis, nil
+}
+
+// loadTx loads the transaction of outpoint from the source
+func (t *OriginTracker) loadTx(ctx context.Context, outpoint *transaction.Outpoint) (*transaction.Transaction, error) {
+	if t.Source == nil {
+		return nil, lib.ErrNoTxSource
+	}
+	return t.Source.LoadTx(ctx, &outpoint.Txid)
+}
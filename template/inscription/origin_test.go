@@ -0,0 +1,193 @@
+package inscription
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitcoin-sv/go-templates/lib"
+	"github.com/bitcoin-sv/go-templates/template/bitcom"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// originTestTx returns a transaction spending inputs and paying outputs
+func originTestTx(inputs []*transaction.Outpoint, outputs ...*transaction.TransactionOutput) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	for _, in := range inputs {
+		tx.AddInput(&transaction.TransactionInput{
+			SourceTXID:       &in.Txid,
+			SourceTxOutIndex: in.Index,
+			UnlockingScript:  &script.Script{},
+			SequenceNumber:   transaction.DefaultSequenceNumber,
+		})
+	}
+	for _, out := range outputs {
+		tx.AddOutput(out)
+	}
+	return tx
+}
+
+// mapScript returns an OP_RETURN script carrying a MAP command
+func mapScript(t *testing.T, args ...string) *script.Script {
+	t.Helper()
+	data := make([][]byte, len(args))
+	for i, arg := range args {
+		data[i] = []byte(arg)
+	}
+	s, err := bitcom.NewBuilder().AddProtocol(bitcom.MapPrefix, data...).Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	return s
+}
+
+// inscriptionScript returns an inscription followed by a MAP command
+func inscriptionScript(t *testing.T, content string, mapArgs ...string) *script.Script {
+	t.Helper()
+	insc := &Inscription{
+		File:         File{Type: "text/plain", Content: []byte(content)},
+		ScriptSuffix: *mapScript(t, mapArgs...),
+	}
+	s, err := insc.Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	return s
+}
+
+func outpoint(tx *transaction.Transaction, vout uint32) *transaction.Outpoint {
+	return &transaction.Outpoint{Txid: *tx.TxID(), Index: vout}
+}
+
+// originTestChain returns transactions moving an inscribed satoshi through
+// several transfers, and the final outpoint holding it
+func originTestChain(t *testing.T) ([]*transaction.Transaction, []*transaction.Outpoint) {
+	t.Helper()
+	plain := &script.Script{script.OpTRUE}
+	coinbase := originTestTx(
+		[]*transaction.Outpoint{{Index: 0xffffffff}},
+		&transaction.TransactionOutput{Satoshis: 5000, LockingScript: plain},
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: plain},
+	)
+	// Inscribe the first satoshi of a larger output
+	mint := originTestTx(
+		[]*transaction.Outpoint{outpoint(coinbase, 0)},
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: inscriptionScript(t, "first", "SET", "app", "test", "name", "original")},
+		&transaction.TransactionOutput{Satoshis: 4990, LockingScript: plain},
+	)
+	// Transfer at the same offset, updating the metadata
+	transfer := originTestTx(
+		[]*transaction.Outpoint{outpoint(mint, 0), outpoint(mint, 1)},
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: mapScript(t, "SET", "app", "test", "name", "renamed")},
+		&transaction.TransactionOutput{Satoshis: 4980, LockingScript: plain},
+	)
+	// Transfer behind the change, so the satoshi moves to a later output
+	moved := originTestTx(
+		[]*transaction.Outpoint{outpoint(transfer, 1), outpoint(transfer, 0)},
+		&transaction.TransactionOutput{Satoshis: 4980, LockingScript: plain},
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: mapScript(t, "ADD", "tags", "rare")},
+	)
+	// Inscribing an already inscribed satoshi updates it
+	reinscribed := originTestTx(
+		[]*transaction.Outpoint{outpoint(moved, 1), outpoint(moved, 0)},
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: inscriptionScript(t, "second", "SET", "app", "test", "edition", "2")},
+		&transaction.TransactionOutput{Satoshis: 4970, LockingScript: plain},
+	)
+
+	txs := []*transaction.Transaction{coinbase, mint, transfer, moved, reinscribed}
+	return txs, []*transaction.Outpoint{outpoint(mint, 0), outpoint(transfer, 0), outpoint(moved, 1), outpoint(reinscribed, 0)}
+}
+
+func TestOriginTracker_Trace(t *testing.T) {
+	txs, provenance := originTestChain(t)
+	tracker := NewOriginTracker(lib.NewMemoryTxSource(txs...))
+
+	origin, err := tracker.Trace(context.Background(), provenance[3])
+	if err != nil {
+		t.Fatalf("Trace error: %v", err)
+	}
+	if origin.Outpoint.String() != provenance[0].String() {
+		t.Errorf("Outpoint mismatch: got %s, want %s", origin.Outpoint, provenance[0])
+	}
+	if string(origin.Inscription.File.Content) != "first" {
+		t.Errorf("Inscription should be the original: got %q", origin.Inscription.File.Content)
+	}
+	if len(origin.Provenance) != len(provenance) {
+		t.Fatalf("Provenance length mismatch: got %d, want %d", len(origin.Provenance), len(provenance))
+	}
+	for i := range provenance {
+		if origin.Provenance[i].String() != provenance[i].String() {
+			t.Errorf("Provenance[%d] mismatch: got %s, want %s", i, origin.Provenance[i], provenance[i])
+		}
+	}
+	want := map[string]string{"app": "test", "name": "renamed", "tags": "rare", "edition": "2"}
+	for key, value := range want {
+		if got, _ := origin.Metadata.Get(key); got != value {
+			t.Errorf("Metadata %s mismatch: got %q, want %q", key, got, value)
+		}
+	}
+
+	// Tracing part way along the chain only applies earlier metadata
+	origin, err = tracker.Trace(context.Background(), provenance[1])
+	if err != nil {
+		t.Fatalf("Trace error: %v", err)
+	}
+	if _, ok := origin.Metadata.Get("tags"); ok || len(origin.Provenance) != 2 {
+		t.Errorf("Trace should stop at the traced outpoint: got %+v", origin)
+	}
+}
+
+func TestOriginTracker_Errors(t *testing.T) {
+	txs, provenance := originTestChain(t)
+	ctx := context.Background()
+	tracker := NewOriginTracker(lib.NewMemoryTxSource(txs...))
+
+	if _, err := tracker.Trace(ctx, outpoint(txs[1], 1)); !errors.Is(err, ErrNotOrdinal) {
+		t.Errorf("expected ErrNotOrdinal, got %v", err)
+	}
+	if _, err := tracker.Trace(ctx, outpoint(txs[0], 1)); !errors.Is(err, ErrNoInscription) {
+		t.Errorf("expected ErrNoInscription, got %v", err)
+	}
+	if _, err := tracker.Trace(ctx, outpoint(txs[1], 5)); !errors.Is(err, ErrOutputNotFound) {
+		t.Errorf("expected ErrOutputNotFound, got %v", err)
+	}
+
+	tracker.MaxDepth = 2
+	if _, err := tracker.Trace(ctx, provenance[3]); !errors.Is(err, ErrTraceTooDeep) {
+		t.Errorf("expected ErrTraceTooDeep, got %v", err)
+	}
+	tracker.MaxDepth = 3
+	if _, err := tracker.Trace(ctx, provenance[3]); err != nil {
+		t.Errorf("Trace error: %v", err)
+	}
+
+	// A missing transaction in the chain cannot be skipped
+	missing := NewOriginTracker(lib.NewMemoryTxSource(txs[0], txs[2], txs[3]))
+	if _, err := missing.Trace(ctx, provenance[2]); !errors.Is(err, lib.ErrTxNotFound) {
+		t.Errorf("expected ErrTxNotFound, got %v", err)
+	}
+	if _, err := NewOriginTracker(nil).Trace(ctx, provenance[0]); !errors.Is(err, lib.ErrNoTxSource) {
+		t.Errorf("expected ErrNoTxSource, got %v", err)
+	}
+}
+
+func TestOriginTracker_DirTxSource(t *testing.T) {
+	txs, provenance := originTestChain(t)
+	dir := t.TempDir()
+	for _, tx := range txs {
+		if err := os.WriteFile(filepath.Join(dir, tx.TxID().String()+".hex"), []byte(tx.Hex()+"\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+	}
+
+	origin, err := NewOriginTracker(lib.DirTxSource(dir)).Trace(context.Background(), provenance[2])
+	if err != nil {
+		t.Fatalf("Trace error: %v", err)
+	}
+	if origin.Outpoint.String() != provenance[0].String() {
+		t.Errorf("Outpoint mismatch: got %s, want %s", origin.Outpoint, provenance[0])
+	}
+}
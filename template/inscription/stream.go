@@ -0,0 +1,263 @@
+package inscription
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+var (
+	ErrNoInscription        = errors.New("no inscription found")
+	ErrUnterminatedEnvelope = errors.New("inscription envelope not terminated by OP_ENDIF")
+)
+
+// LockTo writes the locking script to w as Lock does, but with size bytes of
+// content read from content in place of File.Content, so large content is
+// copied straight to w rather than held in memory. Returns the number of bytes
+// written.
+func (i *Inscription) LockTo(w io.Writer, content io.Reader, size int64) (int64, error) {
+	header, err := i.header()
+	if err != nil {
+		return 0, err
+	}
+	prefix, err := pushPrefix(size)
+	if err != nil {
+		return 0, err
+	}
+
+	head := make([]byte, 0, len(i.ScriptPrefix)+len(header)+1+len(prefix))
+	head = append(head, i.ScriptPrefix...)
+	head = append(head, header...)
+	head = append(head, script.Op0)
+	head = append(head, prefix...)
+	n, err := w.Write(head)
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	copied, err := io.CopyN(w, content, size)
+	written += copied
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return written, err
+	}
+
+	n, err = w.Write(append([]byte{script.OpENDIF}, i.ScriptSuffix...))
+	return written + int64(n), err
+}
+
+// Decoder reads an inscription from a locking script in an io.Reader, streaming
+// the content rather than reading it into memory. The script before the envelope
+// and each field are read into memory, which Decode would hold anyway.
+//
+//	d := inscription.NewDecoder(r)
+//	insc, err := d.Decode()
+//	...
+//	_, err = io.Copy(f, d) // Content as inscribed, before removing any encoding
+//	err = d.Close()        // Sets insc.File.Hash and insc.ScriptSuffix
+//
+// DecodedReader reads the content with its encoding removed instead, limited by
+// the options passed to NewDecoder.
+type Decoder struct {
+	r         *bufio.Reader
+	opts      *decodeOptions
+	insc      *Inscription
+	remaining int64
+	hash      hash.Hash
+}
+
+// NewDecoder returns a decoder reading a locking script from r
+func NewDecoder(r io.Reader, opts ...DecodeOption) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), opts: newDecodeOptions(opts)}
+}
+
+// Decode reads the script up to the content of the first inscription, and
+// returns the inscription with File.Size set and File.Content empty. Read the
+// content from the decoder, then call Close to set File.Hash and ScriptSuffix.
+// Returns ErrNoInscription if the script ends before an envelope is found.
+func (d *Decoder) Decode() (*Inscription, error) {
+	var raw []byte
+	for {
+		start := len(raw)
+		op, data, err := d.readOp(&raw)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, ErrNoInscription
+			}
+			return nil, err
+		}
+		if start >= 2 && op == script.OpDATA3 && bytes.Equal(data, []byte("ord")) && raw[start-2] == 0 && raw[start-1] == script.OpIF {
+			d.insc = &Inscription{
+				ScriptPrefix: raw[:start-2],
+				envelope:     &envelope{},
+			}
+			break
+		}
+	}
+
+	for {
+		var fieldRaw []byte
+		op, tag, err := d.readOp(&fieldRaw)
+		if err != nil || op > script.Op16 {
+			if err == nil && op == script.OpENDIF {
+				// An envelope without content, such as a delegate
+				if d.insc.ScriptSuffix, err = io.ReadAll(d.r); err != nil {
+					return nil, err
+				}
+			}
+			return d.insc.decoded(), nil
+		}
+		tagLen := len(fieldRaw)
+		op2, size, err := d.readPushHeader(&fieldRaw)
+		if err != nil || op2 > script.Op16 {
+			return d.insc.decoded(), nil
+		}
+		entry := &envelopeEntry{
+			tag:    parseTag(&script.ScriptChunk{Op: op, Data: tag}),
+			tagLen: tagLen,
+		}
+		if entry.tag == TagContent {
+			d.insc.File.Size = uint32(size)
+			d.remaining = size
+			d.hash = sha256.New()
+			return d.insc.decoded(), nil
+		}
+		if entry.value, err = d.readData(&fieldRaw, size); err != nil {
+			return d.insc.decoded(), nil
+		}
+		entry.raw = fieldRaw
+		d.insc.addEntry(entry)
+	}
+}
+
+// Read reads the inscription content, as inscribed
+func (d *Decoder) Read(p []byte) (int, error) {
+	if d.hash == nil || d.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.r.Read(p)
+	d.hash.Write(p[:n])
+	d.remaining -= int64(n)
+	if errors.Is(err, io.EOF) && d.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// DecodedReader returns a reader of the content with File.Encoding removed, which
+// fails with ErrDecodedTooLarge past the size limit of the decoder's options.
+// Call it after Decode, and read the content from either it or the decoder.
+func (d *Decoder) DecodedReader() (io.Reader, error) {
+	if d.insc == nil {
+		return nil, ErrNoInscription
+	}
+	return decompressReader(d.insc.File.Encoding, d, d.opts)
+}
+
+// Close discards any unread content and reads the rest of the script, setting
+// File.Hash and ScriptSuffix of the decoded inscription
+func (d *Decoder) Close() error {
+	if d.hash == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, d); err != nil {
+		return err
+	}
+	d.insc.File.Hash = d.hash.Sum(nil)
+	d.hash = nil
+
+	op, err := d.r.ReadByte()
+	if errors.Is(err, io.EOF) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if op != script.OpENDIF {
+		return ErrUnterminatedEnvelope
+	}
+	d.insc.ScriptSuffix, err = io.ReadAll(d.r)
+	return err
+}
+
+// readOp reads an opcode and its data, appending the bytes read to raw
+func (d *Decoder) readOp(raw *[]byte) (byte, []byte, error) {
+	op, size, err := d.readPushHeader(raw)
+	if err != nil {
+		return op, nil, err
+	}
+	data, err := d.readData(raw, size)
+	return op, data, err
+}
+
+// readPushHeader reads an opcode and the length of the data it pushes,
+// appending the bytes read to raw
+func (d *Decoder) readPushHeader(raw *[]byte) (byte, int64, error) {
+	op, err := d.r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	*raw = append(*raw, op)
+
+	var lenBytes int
+	switch {
+	case op > script.Op0 && op < script.OpPUSHDATA1:
+		return op, int64(op), nil
+	case op == script.OpPUSHDATA1:
+		lenBytes = 1
+	case op == script.OpPUSHDATA2:
+		lenBytes = 2
+	case op == script.OpPUSHDATA4:
+		lenBytes = 4
+	default:
+		return op, 0, nil
+	}
+	var buf [4]byte
+	if _, err := io.ReadFull(d.r, buf[:lenBytes]); err != nil {
+		return op, 0, io.ErrUnexpectedEOF
+	}
+	*raw = append(*raw, buf[:lenBytes]...)
+	return op, int64(binary.LittleEndian.Uint32(buf[:])), nil
+}
+
+// readData reads size bytes of push data, appending them to raw
+func (d *Decoder) readData(raw *[]byte, size int64) ([]byte, error) {
+	start := len(*raw)
+	// Grow as data arrives rather than trusting the length up front
+	buf := bytes.NewBuffer(*raw)
+	_, err := io.CopyN(buf, d.r, size)
+	*raw = buf.Bytes()
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	return (*raw)[start:], nil
+}
+
+// pushPrefix returns the opcode and length bytes that push size bytes of data
+func pushPrefix(size int64) ([]byte, error) {
+	switch {
+	case size < 0:
+		return nil, script.ErrDataTooBig
+	case size < int64(script.OpPUSHDATA1):
+		return []byte{byte(size)}, nil
+	case size <= 0xff:
+		return []byte{script.OpPUSHDATA1, byte(size)}, nil
+	case size <= 0xffff:
+		return binary.LittleEndian.AppendUint16([]byte{script.OpPUSHDATA2}, uint16(size)), nil
+	case size <= 0xffffffff:
+		return binary.LittleEndian.AppendUint32([]byte{script.OpPUSHDATA4}, uint32(size)), nil
+	}
+	return nil, script.ErrDataTooBig
+}
@@ -0,0 +1,247 @@
+package inscription
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// testInscription returns an inscription with fields, a prefix and a suffix
+func testInscription(content []byte) *Inscription {
+	pointer := uint64(1)
+	return &Inscription{
+		File:         File{Type: "application/octet-stream", Content: content},
+		Pointer:      &pointer,
+		Metaprotocol: "test",
+		Fields:       []*Field{{Tag: 31, Value: []byte("unknown")}},
+		ScriptPrefix: []byte{script.OpTRUE, script.OpDROP},
+		ScriptSuffix: []byte{script.OpDUP, script.OpHASH160},
+	}
+}
+
+func TestLockTo(t *testing.T) {
+	for _, size := range []int{0, 75, 76, 256, 70000} {
+		content := bytes.Repeat([]byte{0xab}, size)
+		insc := testInscription(content)
+		want, err := insc.Lock()
+		if err != nil {
+			t.Fatalf("Lock error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		n, err := insc.LockTo(&buf, bytes.NewReader(content), int64(size))
+		if err != nil {
+			t.Fatalf("LockTo error: %v", err)
+		}
+		if n != int64(len(*want)) || !bytes.Equal(buf.Bytes(), *want) {
+			t.Errorf("LockTo with %d bytes should write the same script as Lock", size)
+		}
+	}
+
+	// Content shorter than the size is an error
+	if _, err := testInscription(nil).LockTo(io.Discard, bytes.NewReader([]byte("short")), 10); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	content := bytes.Repeat([]byte("streamed "), 10000)
+	s, err := testInscription(content).Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	want := Decode(s)
+
+	d := NewDecoder(bytes.NewReader(*s))
+	insc, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if insc.File.Size != uint32(len(content)) || insc.File.Type != want.File.Type {
+		t.Errorf("File mismatch: got %+v", insc.File)
+	}
+	if insc.Pointer == nil || *insc.Pointer != 1 || insc.Metaprotocol != "test" || len(insc.Fields) != 1 {
+		t.Errorf("Fields mismatch: got %+v", insc)
+	}
+	if !bytes.Equal(insc.ScriptPrefix, want.ScriptPrefix) {
+		t.Errorf("ScriptPrefix mismatch: got %x, want %x", insc.ScriptPrefix, want.ScriptPrefix)
+	}
+
+	streamed, err := io.ReadAll(d)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if !bytes.Equal(streamed, content) {
+		t.Errorf("Content mismatch: got %d bytes, want %d", len(streamed), len(content))
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if !bytes.Equal(insc.File.Hash, want.File.Hash) {
+		t.Errorf("File.Hash mismatch: got %x, want %x", insc.File.Hash, want.File.Hash)
+	}
+	if !bytes.Equal(insc.ScriptSuffix, want.ScriptSuffix) {
+		t.Errorf("ScriptSuffix mismatch: got %x, want %x", insc.ScriptSuffix, want.ScriptSuffix)
+	}
+
+	// The streamed header locks back to the same script
+	var buf bytes.Buffer
+	if _, err := insc.LockTo(&buf, bytes.NewReader(streamed), int64(len(streamed))); err != nil {
+		t.Fatalf("LockTo error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), *s) {
+		t.Errorf("LockTo after streaming decode should reproduce the script")
+	}
+}
+
+func TestDecoder_CloseUnread(t *testing.T) {
+	s, err := testInscription([]byte("unread content")).Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	d := NewDecoder(bytes.NewReader(*s))
+	insc, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if want := Decode(s); !bytes.Equal(insc.File.Hash, want.File.Hash) {
+		t.Errorf("File.Hash mismatch: got %x, want %x", insc.File.Hash, want.File.Hash)
+	}
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	if _, err := NewDecoder(bytes.NewReader([]byte{0x00, 0x51, 0x52})).Decode(); !errors.Is(err, ErrNoInscription) {
+		t.Errorf("expected ErrNoInscription, got %v", err)
+	}
+
+	// Truncated content
+	s, err := testInscription(bytes.Repeat([]byte{1}, 100)).Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	d := NewDecoder(bytes.NewReader((*s)[:len(*s)-50]))
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if _, err := io.ReadAll(d); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// Content not followed by OP_ENDIF
+	unterminated := append(bytes.Clone((*s)[:len(*s)-3]), script.OpNOP)
+	d = NewDecoder(bytes.NewReader(unterminated))
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if err := d.Close(); !errors.Is(err, ErrUnterminatedEnvelope) {
+		t.Errorf("expected ErrUnterminatedEnvelope, got %v", err)
+	}
+}
+
+const benchmarkContentSize = 64 << 20
+
+func BenchmarkLock(b *testing.B) {
+	insc := testInscription(make([]byte, benchmarkContentSize))
+	b.SetBytes(benchmarkContentSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := insc.Lock(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLockTo(b *testing.B) {
+	content := make([]byte, benchmarkContentSize)
+	insc := testInscription(nil)
+	b.SetBytes(benchmarkContentSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := insc.LockTo(io.Discard, bytes.NewReader(content), benchmarkContentSize); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	s, err := testInscription(make([]byte, benchmarkContentSize)).Lock()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(benchmarkContentSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		if Decode(s) == nil {
+			b.Fatal("decode failed")
+		}
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	s, err := testInscription(make([]byte, benchmarkContentSize)).Lock()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(benchmarkContentSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		d := NewDecoder(bytes.NewReader(*s))
+		if _, err := d.Decode(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, d); err != nil {
+			b.Fatal(err)
+		}
+		if err := d.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecoder_DecodedReader(t *testing.T) {
+	content := bytes.Repeat([]byte("streamed "), 1000)
+	f, err := NewFile("text/plain", EncodingBrotli, content)
+	if err != nil {
+		t.Fatalf("NewFile error: %v", err)
+	}
+	s, err := (&Inscription{File: *f}).Lock()
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+
+	d := NewDecoder(bytes.NewReader(*s))
+	if _, err := d.DecodedReader(); !errors.Is(err, ErrNoInscription) {
+		t.Errorf("expected ErrNoInscription before Decode, got %v", err)
+	}
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	r, err := d.DecodedReader()
+	if err != nil {
+		t.Fatalf("DecodedReader error: %v", err)
+	}
+	if decoded, err := io.ReadAll(r); err != nil || !bytes.Equal(decoded, content) {
+		t.Errorf("decoded content mismatch: got %d bytes, want %d, %v", len(decoded), len(content), err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	// The limit is set per decoder
+	d = NewDecoder(bytes.NewReader(*s), WithMaxDecodedSize(int64(len(content)-1)))
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if r, err = d.DecodedReader(); err != nil {
+		t.Fatalf("DecodedReader error: %v", err)
+	}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrDecodedTooLarge) {
+		t.Errorf("expected ErrDecodedTooLarge, got %v", err)
+	}
+}
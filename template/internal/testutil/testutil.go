@@ -0,0 +1,55 @@
+// Package testutil holds fixtures shared by the template tests
+package testutil
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/script/interpreter"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// NewKey returns a new private key and its P2PKH address
+func NewKey(t *testing.T) (*ec.PrivateKey, *script.Address) {
+	t.Helper()
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(key.PubKey(), true)
+	require.NoError(t, err)
+	return key, address
+}
+
+// NewGenesis returns a transaction creating outputs from a coinbase input, so
+// tests can spend them and tracing stops there
+func NewGenesis(outputs ...*transaction.TransactionOutput) *transaction.Transaction {
+	tx := transaction.NewTransaction()
+	tx.AddInput(&transaction.TransactionInput{
+		SourceTXID:       &chainhash.Hash{},
+		SourceTxOutIndex: 0xffffffff,
+		UnlockingScript:  &script.Script{},
+	})
+	for _, out := range outputs {
+		tx.AddOutput(out)
+	}
+	return tx
+}
+
+// RequireValid runs every input of tx through the script interpreter
+func RequireValid(t *testing.T, tx *transaction.Transaction) {
+	t.Helper()
+	for i := range tx.Inputs {
+		require.NoError(t, ExecuteInput(tx, i), "Input %d should verify", i)
+	}
+}
+
+// ExecuteInput runs input inputIndex of tx through the script interpreter
+func ExecuteInput(tx *transaction.Transaction, inputIndex int) error {
+	return interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, inputIndex, tx.Inputs[inputIndex].SourceTxOutput()),
+		interpreter.WithForkID(),
+		interpreter.WithAfterGenesis(),
+	)
+}
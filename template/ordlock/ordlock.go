@@ -2,11 +2,36 @@ package ordlock
 
 import (
 	"bytes"
+	"errors"
 
+	"github.com/bitcoin-sv/go-templates/template/inscription"
+	"github.com/bitcoin-sv/go-templates/template/p2pkh"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	sighash "github.com/bsv-blockchain/go-sdk/transaction/sighash"
 )
 
+var (
+	ErrBadPublicKeyHash = errors.New("invalid public key hash")
+	ErrNoPayOut         = errors.New("payout output not supplied")
+	ErrNoPrivateKey     = errors.New("private key not supplied")
+	ErrNotOrdLock       = errors.New("input is not an OrdLock listing")
+	ErrPayOutMismatch   = errors.New("second output does not match the listing payout")
+)
+
+// PurchaseSigHash is the sighash flag the contract checks the purchase preimage with
+const PurchaseSigHash = sighash.AllForkID | sighash.AnyOneCanPay
+
+// cancelLength is the length of a cancel unlocking script with the largest
+// signature: a 73 byte signature push, a 34 byte public key push and OP_1
+const cancelLength = 108
+
+// purchaseOverhead is the most the purchase unlocking script adds to the listing
+// script and the outputs: the preimage's fixed 156 bytes and script length of up
+// to 9 bytes, three push headers of up to 5 bytes and OP_0
+const purchaseOverhead = 156 + 9 + 3*5 + 1
+
 type OrdLock struct {
 	Seller   *script.Address `json:"seller"`
 	Price    uint64          `json:"price"`
@@ -38,3 +63,136 @@ func Decode(scr *script.Script) *OrdLock {
 		return ordLock
 	}
 }
+
+// Lock returns the OrdLock contract listing an ordinal for sale. The seller can
+// cancel the listing, and anyone can purchase it with a transaction whose second
+// output is payOut, which carries the price and the script it is paid to. If
+// insc is not nil, the contract is prefixed with the inscription, replacing its
+// script suffix.
+func Lock(seller *script.Address, payOut *transaction.TransactionOutput, insc *inscription.Inscription) (*script.Script, error) {
+	if seller == nil || len(seller.PublicKeyHash) != 20 {
+		return nil, ErrBadPublicKeyHash
+	}
+	if payOut == nil || payOut.LockingScript == nil {
+		return nil, ErrNoPayOut
+	}
+	payOutBytes := payOut.Bytes()
+	s := script.Script(make([]byte, 0, len(OrdLockPrefix)+len(payOutBytes)+len(OrdLockSuffix)+24))
+	s = append(s, OrdLockPrefix...)
+	_ = s.AppendPushData(seller.PublicKeyHash)
+	if err := s.AppendPushData(payOutBytes); err != nil {
+		return nil, err
+	}
+	s = append(s, OrdLockSuffix...)
+	if insc == nil {
+		return &s, nil
+	}
+
+	// Lock a copy so the caller's inscription keeps its own suffix
+	prefixed := *insc
+	prefixed.ScriptSuffix = s
+	return prefixed.Lock()
+}
+
+// PurchaseUnlock returns a template that purchases a listing. The purchasing
+// transaction must send the ordinal to its first output and pay the listing's
+// payout in its second output. The remaining outputs, such as change, are
+// unrestricted.
+func PurchaseUnlock() *PurchaseTemplate {
+	return &PurchaseTemplate{}
+}
+
+type PurchaseTemplate struct{}
+
+// Sign returns the unlocking script calling the contract's purchase method with
+// the ordinal output, the outputs after the payout and the input's preimage
+func (p *PurchaseTemplate) Sign(tx *transaction.Transaction, inputIndex uint32) (*script.Script, error) {
+	prevOutput := tx.Inputs[inputIndex].SourceTxOutput()
+	if prevOutput == nil {
+		return nil, transaction.ErrEmptyPreviousTx
+	}
+	listing := Decode(prevOutput.LockingScript)
+	if listing == nil {
+		return nil, ErrNotOrdLock
+	}
+	if len(tx.Outputs) < 2 || !bytes.Equal(tx.Outputs[1].Bytes(), listing.PayOut) {
+		return nil, ErrPayOutMismatch
+	}
+	preimage, err := tx.CalcInputPreimage(inputIndex, PurchaseSigHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var trailing []byte
+	for _, output := range tx.Outputs[2:] {
+		trailing = append(trailing, output.Bytes()...)
+	}
+	s := &script.Script{}
+	if err = s.AppendPushData(tx.Outputs[0].Bytes()); err != nil {
+		return nil, err
+	} else if err = s.AppendPushData(trailing); err != nil {
+		return nil, err
+	} else if err = s.AppendPushData(preimage); err != nil {
+		return nil, err
+	}
+	_ = s.AppendOpcodes(script.Op0)
+	return s, nil
+}
+
+// EstimateLength returns the exact length of the purchase unlocking script, which
+// depends on the transaction's outputs and the listing's locking script. If the
+// input cannot be signed yet, such as before the payout is added, it returns an
+// upper bound for the listing and the outputs so far.
+func (p *PurchaseTemplate) EstimateLength(tx *transaction.Transaction, inputIndex uint32) uint32 {
+	if u, err := p.Sign(tx, inputIndex); err == nil {
+		return uint32(len(*u))
+	}
+	length := purchaseOverhead
+	if prevOutput := tx.Inputs[inputIndex].SourceTxOutput(); prevOutput != nil && prevOutput.LockingScript != nil {
+		length += len(*prevOutput.LockingScript)
+	}
+	for _, output := range tx.Outputs {
+		length += len(output.Bytes())
+	}
+	return uint32(length)
+}
+
+// CancelUnlock returns a template that lets the seller cancel a listing
+func CancelUnlock(key *ec.PrivateKey, sigHashFlag *sighash.Flag) (*CancelTemplate, error) {
+	if key == nil {
+		return nil, ErrNoPrivateKey
+	}
+	if sigHashFlag == nil {
+		shf := sighash.AllForkID
+		sigHashFlag = &shf
+	}
+	return &CancelTemplate{
+		PrivateKey:  key,
+		SigHashFlag: sigHashFlag,
+	}, nil
+}
+
+type CancelTemplate struct {
+	PrivateKey  *ec.PrivateKey
+	SigHashFlag *sighash.Flag
+}
+
+// Sign returns the unlocking script calling the contract's cancel method with
+// the seller's signature and public key
+func (c *CancelTemplate) Sign(tx *transaction.Transaction, inputIndex uint32) (*script.Script, error) {
+	s, err := (&p2pkh.P2PKH{
+		PrivateKey:  c.PrivateKey,
+		SigHashFlag: c.SigHashFlag,
+	}).Sign(tx, inputIndex)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.AppendOpcodes(script.Op1)
+	return s, nil
+}
+
+// EstimateLength returns the length of the cancel unlocking script with the
+// largest signature, so it is never less than the signed length
+func (c *CancelTemplate) EstimateLength(_ *transaction.Transaction, _ uint32) uint32 {
+	return cancelLength
+}
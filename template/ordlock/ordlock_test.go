@@ -1,13 +1,19 @@
 package ordlock
 
 import (
+	"bytes"
 	"encoding/hex"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/bitcoin-sv/go-templates/template/inscription"
+	"github.com/bitcoin-sv/go-templates/template/internal/testutil"
+	"github.com/bitcoin-sv/go-templates/template/p2pkh"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	feemodel "github.com/bsv-blockchain/go-sdk/transaction/fee_model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -223,3 +229,194 @@ func TestDecodeWithTestVector(t *testing.T) {
 		}
 	}
 }
+
+// TestLockWithTestVector verifies that Lock rebuilds the listing in the test
+// vector from its seller and payout
+func TestLockWithTestVector(t *testing.T) {
+	tx, listing, payOut := testVectorListing(t)
+
+	lockScript, err := Lock(listing.Seller, payOut, nil)
+	require.NoError(t, err)
+	require.Equal(t, tx.Outputs[0].LockingScript.Bytes(), lockScript.Bytes())
+}
+
+// TestLockWithInscription verifies that a listing can be prefixed by an inscription
+func TestLockWithInscription(t *testing.T) {
+	publicKeyHash, _ := hex.DecodeString("1234567890abcdef1234567890abcdef12345678")
+	seller, _ := script.NewAddressFromPublicKeyHash(publicKeyHash, true)
+	payTo, err := p2pkh.Lock(seller)
+	require.NoError(t, err)
+	payOut := &transaction.TransactionOutput{Satoshis: 25000, LockingScript: payTo}
+
+	insc := &inscription.Inscription{
+		File:         inscription.File{Type: "text/plain", Content: []byte("for sale")},
+		ScriptSuffix: *payTo,
+	}
+	lockScript, err := Lock(seller, payOut, insc)
+	require.NoError(t, err)
+	require.Equal(t, payTo.Bytes(), []byte(insc.ScriptSuffix), "Inscription should not be modified")
+
+	decodedInsc := inscription.Decode(lockScript)
+	require.NotNil(t, decodedInsc)
+	require.Equal(t, []byte("for sale"), decodedInsc.File.Content)
+	require.True(t, bytes.HasPrefix(decodedInsc.ScriptSuffix, OrdLockPrefix))
+
+	ordLock := Decode(lockScript)
+	require.NotNil(t, ordLock)
+	require.Equal(t, seller.AddressString, ordLock.Seller.AddressString)
+	require.Equal(t, uint64(25000), ordLock.Price)
+	require.Equal(t, payOut.Bytes(), ordLock.PayOut)
+
+	_, err = Lock(&script.Address{}, payOut, nil)
+	require.ErrorIs(t, err, ErrBadPublicKeyHash)
+	_, err = Lock(seller, nil, nil)
+	require.ErrorIs(t, err, ErrNoPayOut)
+}
+
+// testVectorListing returns the transaction in the test vector, the listing in
+// its first output and the listing's payout
+func testVectorListing(t *testing.T) (*transaction.Transaction, *OrdLock, *transaction.TransactionOutput) {
+	t.Helper()
+	hexData, err := os.ReadFile("testdata/690b213114926cd5a6f0785cb3e289afe9cde195972c1d344569c90530b8cbd1.hex")
+	require.NoError(t, err, "Failed to read hex data from file")
+	tx, err := transaction.NewTransactionFromHex(strings.TrimSpace(string(hexData)))
+	require.NoError(t, err, "Failed to create transaction from bytes")
+
+	listing := Decode(tx.Outputs[0].LockingScript)
+	require.NotNil(t, listing)
+	payOut := &transaction.TransactionOutput{}
+	_, err = payOut.ReadFrom(bytes.NewReader(listing.PayOut))
+	require.NoError(t, err)
+	return tx, listing, payOut
+}
+
+// listingFixture lists an inscription for sale and funds a buyer and the seller
+type listingFixture struct {
+	seller, buyer       *ec.PrivateKey
+	sellerAddr, buyAddr *script.Address
+	payOut              *transaction.TransactionOutput
+	genesis             *transaction.Transaction
+}
+
+// buyerFunds funds the buyer enough to purchase the test vector's listing
+const buyerFunds = 2000000
+
+// newListingFixture lists an ordinal in output 0 of a genesis transaction, with
+// funding for the buyer in output 1 and for the seller in output 2
+func newListingFixture(t *testing.T, price uint64) *listingFixture {
+	t.Helper()
+	f := &listingFixture{}
+	f.seller, f.sellerAddr = testutil.NewKey(t)
+	f.buyer, f.buyAddr = testutil.NewKey(t)
+
+	payTo, err := p2pkh.Lock(f.sellerAddr)
+	require.NoError(t, err)
+	f.payOut = &transaction.TransactionOutput{Satoshis: price, LockingScript: payTo}
+	listing, err := Lock(f.sellerAddr, f.payOut, &inscription.Inscription{
+		File: inscription.File{Type: "text/plain", Content: []byte("for sale")},
+	})
+	require.NoError(t, err)
+	buyerScript, err := p2pkh.Lock(f.buyAddr)
+	require.NoError(t, err)
+
+	f.genesis = testutil.NewGenesis(
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: listing},
+		&transaction.TransactionOutput{Satoshis: buyerFunds, LockingScript: buyerScript},
+		&transaction.TransactionOutput{Satoshis: 1000, LockingScript: payTo},
+	)
+	return f
+}
+
+// TestPurchaseUnlock verifies that a buyer can purchase a listing by paying its payout
+func TestPurchaseUnlock(t *testing.T) {
+	f := newListingFixture(t, 25000)
+	vector, _, vectorPayOut := testVectorListing(t)
+	buyerUnlock, err := p2pkh.Unlock(f.buyer, nil)
+	require.NoError(t, err)
+	ordScript, err := p2pkh.Lock(f.buyAddr)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		listed  *transaction.Transaction // Holds the listing in output 0
+		payOut  *transaction.TransactionOutput
+		trailer bool // Adds a change output after the payout, otherwise the buyer pays the fee from the difference
+	}{
+		{name: "with change", listed: f.genesis, payOut: f.payOut, trailer: true},
+		{name: "without change", listed: f.genesis, payOut: f.payOut},
+		{name: "test vector listing", listed: vector, payOut: vectorPayOut, trailer: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := transaction.NewTransaction()
+			tx.AddInputFromTx(tt.listed, 0, PurchaseUnlock())
+			tx.AddInputFromTx(f.genesis, 1, buyerUnlock)
+			tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: ordScript})
+			tx.AddOutput(tt.payOut)
+			if tt.trailer {
+				tx.AddOutput(&transaction.TransactionOutput{LockingScript: ordScript, Change: true})
+				require.NoError(t, tx.Fee(&feemodel.SatoshisPerKilobyte{Satoshis: 1}, transaction.ChangeDistributionEqual))
+			}
+
+			estimate := PurchaseUnlock().EstimateLength(tx, 0)
+			require.NoError(t, tx.Sign())
+			require.Equal(t, uint32(len(*tx.Inputs[0].UnlockingScript)), estimate, "Estimate should be exact")
+			testutil.RequireValid(t, tx)
+
+			// The contract rejects a purchase that underpays the seller
+			tx.Outputs[1] = &transaction.TransactionOutput{Satoshis: tt.payOut.Satoshis - 1, LockingScript: tt.payOut.LockingScript}
+			require.Error(t, testutil.ExecuteInput(tx, 0))
+			_, err := PurchaseUnlock().Sign(tx, 0)
+			require.ErrorIs(t, err, ErrPayOutMismatch)
+			require.GreaterOrEqual(t, PurchaseUnlock().EstimateLength(tx, 0), estimate, "Estimate should bound an unsigned purchase")
+
+			_, err = PurchaseUnlock().Sign(tx, 1)
+			require.ErrorIs(t, err, ErrNotOrdLock)
+		})
+	}
+}
+
+// TestCancelUnlock verifies that only the seller can cancel a listing
+func TestCancelUnlock(t *testing.T) {
+	f := newListingFixture(t, 25000)
+	sellerUnlock, err := p2pkh.Unlock(f.seller, nil)
+	require.NoError(t, err)
+	ordScript, err := p2pkh.Lock(f.sellerAddr)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		signer *ec.PrivateKey
+		valid  bool
+	}{
+		{name: "seller", signer: f.seller, valid: true},
+		{name: "buyer", signer: f.buyer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancel, err := CancelUnlock(tt.signer, nil)
+			require.NoError(t, err)
+
+			tx := transaction.NewTransaction()
+			tx.AddInputFromTx(f.genesis, 0, cancel)
+			tx.AddInputFromTx(f.genesis, 2, sellerUnlock)
+			tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: ordScript})
+			tx.AddOutput(&transaction.TransactionOutput{LockingScript: ordScript, Change: true})
+			require.NoError(t, tx.Fee(&feemodel.SatoshisPerKilobyte{Satoshis: 1}, transaction.ChangeDistributionEqual))
+			require.NoError(t, tx.Sign())
+			require.LessOrEqual(t, uint32(len(*tx.Inputs[0].UnlockingScript)), cancel.EstimateLength(tx, 0))
+			require.GreaterOrEqual(t, uint32(len(*tx.Inputs[0].UnlockingScript)), cancel.EstimateLength(tx, 0)-2)
+
+			err = testutil.ExecuteInput(tx, 0)
+			if !tt.valid {
+				require.Error(t, err, "Anyone else's signature is rejected")
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireValid(t, tx)
+		})
+	}
+
+	_, err = CancelUnlock(nil, nil)
+	require.ErrorIs(t, err, ErrNoPrivateKey)
+}
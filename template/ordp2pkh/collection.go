@@ -0,0 +1,430 @@
+package ordp2pkh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitcoin-sv/go-templates/template/bitcom"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// MAP keys and values of 1Sat Ordinals collection metadata
+const (
+	MapTypeOrd            = "ord"
+	SubTypeCollection     = "collection"
+	SubTypeCollectionItem = "collectionItem"
+
+	MapKeyApp         = "app"
+	MapKeyType        = "type"
+	MapKeyName        = "name"
+	MapKeySubType     = "subType"
+	MapKeySubTypeData = "subTypeData"
+	MapKeyRoyalties   = "royalties"
+	MapKeyPreviewURL  = "previewUrl"
+)
+
+// Royalty destination types
+const (
+	RoyaltyPaymail = "paymail"
+	RoyaltyAddress = "address"
+	RoyaltyScript  = "script"
+)
+
+var (
+	ErrNotCollection      = errors.New("metadata is not 1Sat collection metadata")
+	ErrInvalidCollection  = errors.New("invalid collection metadata")
+	ErrInvalidSubTypeData = errors.New("invalid subTypeData")
+)
+
+// RarityLabel names a rarity tier and the share of the collection in it, such as
+// "Legendary" and "5%". It is encoded as a single entry JSON object, {"Legendary": "5%"}.
+type RarityLabel struct {
+	Label      string
+	Percentage string
+}
+
+// MarshalJSON encodes the label as {"<label>": "<percentage>"}
+func (r RarityLabel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{r.Label: r.Percentage})
+}
+
+// UnmarshalJSON decodes a label encoded as {"<label>": "<percentage>"}
+func (r *RarityLabel) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if len(m) != 1 {
+		return fmt.Errorf("%w: rarity label must have a single entry", ErrInvalidSubTypeData)
+	}
+	for label, percentage := range m {
+		r.Label, r.Percentage = label, percentage
+	}
+	return nil
+}
+
+// CollectionTrait is a trait of the items in a collection, with the values it
+// takes and the share of items with each value
+type CollectionTrait struct {
+	Values                []string `json:"values"`
+	OccurrencePercentages []string `json:"occurancePercentages"` // Spelled as in the 1Sat specification
+}
+
+// Royalty is a share of each sale paid to a destination
+type Royalty struct {
+	Type        string `json:"type"`        // RoyaltyPaymail, RoyaltyAddress or RoyaltyScript
+	Destination string `json:"destination"` // Paymail, address or hex locking script
+	Percentage  string `json:"percentage"`  // Share of the sale, e.g. "0.05" for 5%
+}
+
+// Collection is the metadata of a 1Sat Ordinals collection inscription. The
+// royalties and preview URL are MAP keys of their own, beside subTypeData.
+//
+//	MAP SET app <app> type ord name <name> subType collection subTypeData <json>
+//	    [royalties <json>] [previewUrl <url>]
+type Collection struct {
+	App          string                      `json:"app"`
+	Name         string                      `json:"name"`
+	Description  string                      `json:"description"`
+	Quantity     uint64                      `json:"quantity"` // Number of items in the collection
+	RarityLabels []RarityLabel               `json:"rarityLabels,omitempty"`
+	Traits       map[string]*CollectionTrait `json:"traits,omitempty"`
+	PreviewURL   string                      `json:"previewUrl,omitempty"`
+	Royalties    []*Royalty                  `json:"royalties,omitempty"`
+}
+
+// collectionData is the subTypeData of a collection
+type collectionData struct {
+	Description  string                      `json:"description"`
+	Quantity     uint64                      `json:"quantity"`
+	RarityLabels []RarityLabel               `json:"rarityLabels,omitempty"`
+	Traits       map[string]*CollectionTrait `json:"traits,omitempty"`
+}
+
+// CollectionItemTrait is the value of a trait of a collection item
+type CollectionItemTrait struct {
+	Name                 string `json:"name"`
+	Value                string `json:"value"`
+	RarityLabel          string `json:"rarityLabel,omitempty"`
+	OccurrencePercentage string `json:"occurancePercentrage,omitempty"` // Spelled as in the 1Sat specification
+}
+
+// CollectionItem is the metadata of an inscription in a 1Sat Ordinals collection
+//
+//	MAP SET app <app> type ord name <name> subType collectionItem subTypeData <json>
+type CollectionItem struct {
+	App          string                 `json:"app"`
+	Name         string                 `json:"name"`
+	CollectionID *transaction.Outpoint  `json:"collectionId"` // Outpoint of the collection inscription
+	MintNumber   uint64                 `json:"mintNumber,omitempty"`
+	Rank         uint64                 `json:"rank,omitempty"`
+	RarityLabel  []RarityLabel          `json:"rarityLabel,omitempty"`
+	Traits       []*CollectionItemTrait `json:"traits,omitempty"`
+}
+
+// collectionItemData is the subTypeData of a collection item
+type collectionItemData struct {
+	CollectionID string                 `json:"collectionId"`
+	MintNumber   uint64                 `json:"mintNumber,omitempty"`
+	Rank         uint64                 `json:"rank,omitempty"`
+	RarityLabel  []RarityLabel          `json:"rarityLabel,omitempty"`
+	Traits       []*CollectionItemTrait `json:"traits,omitempty"`
+}
+
+// Validate checks the collection has an app, a name and a quantity, that rarity
+// labels and trait occurrences are percentages adding up to no more than 100%,
+// and that royalties have a destination and add up to less than the sale
+func (c *Collection) Validate() error {
+	if c.App == "" {
+		return fmt.Errorf("%w: missing app", ErrInvalidCollection)
+	} else if c.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidCollection)
+	} else if c.Quantity == 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidCollection)
+	}
+	if err := validateRarityLabels(c.RarityLabels); err != nil {
+		return err
+	}
+
+	// Sort trait names so the first error is always the same
+	names := make([]string, 0, len(c.Traits))
+	for name := range c.Traits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		trait := c.Traits[name]
+		if name == "" || trait == nil {
+			return fmt.Errorf("%w: empty trait", ErrInvalidCollection)
+		}
+		if len(trait.OccurrencePercentages) > 0 && len(trait.OccurrencePercentages) != len(trait.Values) {
+			return fmt.Errorf("%w: trait %s has %d values and %d occurrence percentages",
+				ErrInvalidCollection, name, len(trait.Values), len(trait.OccurrencePercentages))
+		}
+		if _, err := sumPercentages(trait.OccurrencePercentages); err != nil {
+			return fmt.Errorf("%w: trait %s: %w", ErrInvalidCollection, name, err)
+		}
+	}
+
+	var royalties []string
+	for _, r := range c.Royalties {
+		switch {
+		case r == nil:
+			return fmt.Errorf("%w: empty royalty", ErrInvalidCollection)
+		case r.Type != RoyaltyPaymail && r.Type != RoyaltyAddress && r.Type != RoyaltyScript:
+			return fmt.Errorf("%w: unknown royalty type %q", ErrInvalidCollection, r.Type)
+		case r.Destination == "":
+			return fmt.Errorf("%w: royalty missing destination", ErrInvalidCollection)
+		}
+		royalties = append(royalties, r.Percentage)
+	}
+	if total, err := sumPercentages(royalties); err != nil {
+		return fmt.Errorf("%w: royalties: %w", ErrInvalidCollection, err)
+	} else if total >= 1 {
+		return fmt.Errorf("%w: royalties total %s of the sale", ErrInvalidCollection, formatPercentage(total))
+	}
+	return nil
+}
+
+// Map validates the collection and returns its MAP metadata, to lock with
+// LockWithMapMetadata
+func (c *Collection) Map() (*bitcom.Map, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&collectionData{
+		Description:  c.Description,
+		Quantity:     c.Quantity,
+		RarityLabels: c.RarityLabels,
+		Traits:       c.Traits,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m := ordMap(c.App, c.Name, SubTypeCollection, data)
+	if len(c.Royalties) > 0 {
+		royalties, err := json.Marshal(c.Royalties)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(MapKeyRoyalties, string(royalties))
+	}
+	if c.PreviewURL != "" {
+		m.Set(MapKeyPreviewURL, c.PreviewURL)
+	}
+	return m, nil
+}
+
+// DecodeCollection decodes collection metadata from MAP metadata. Returns
+// ErrNotCollection if the metadata is not for a collection. The collection is
+// not validated, as inscribed metadata may not follow the rules; use Validate.
+func DecodeCollection(m *bitcom.Map) (*Collection, error) {
+	var data collectionData
+	app, name, err := decodeOrdMap(m, SubTypeCollection, &data)
+	if err != nil {
+		return nil, err
+	}
+	collection := &Collection{
+		App:          app,
+		Name:         name,
+		Description:  data.Description,
+		Quantity:     data.Quantity,
+		RarityLabels: data.RarityLabels,
+		Traits:       data.Traits,
+	}
+	collection.PreviewURL, _ = m.Get(MapKeyPreviewURL)
+	if raw, ok := m.Get(MapKeyRoyalties); ok {
+		if err := json.Unmarshal([]byte(raw), &collection.Royalties); err != nil {
+			return nil, fmt.Errorf("%w: royalties: %w", ErrInvalidCollection, err)
+		}
+	}
+	return collection, nil
+}
+
+// Validate checks the item has an app, a name and a collection, and that its
+// traits are named
+func (ci *CollectionItem) Validate() error {
+	if ci.App == "" {
+		return fmt.Errorf("%w: missing app", ErrInvalidCollection)
+	} else if ci.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidCollection)
+	} else if ci.CollectionID == nil {
+		return fmt.Errorf("%w: missing collection id", ErrInvalidCollection)
+	}
+	if err := validateRarityLabels(ci.RarityLabel); err != nil {
+		return err
+	}
+	for _, trait := range ci.Traits {
+		if trait == nil || trait.Name == "" || trait.Value == "" {
+			return fmt.Errorf("%w: trait missing name or value", ErrInvalidCollection)
+		}
+		if trait.OccurrencePercentage != "" {
+			if _, err := parsePercentage(trait.OccurrencePercentage); err != nil {
+				return fmt.Errorf("%w: trait %s: %w", ErrInvalidCollection, trait.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateFor validates the item and checks it belongs to a collection of the
+// given quantity, which its mint number must not exceed
+func (ci *CollectionItem) ValidateFor(collectionID *transaction.Outpoint, quantity uint64) error {
+	if err := ci.Validate(); err != nil {
+		return err
+	} else if collectionID == nil || !ci.CollectionID.Equal(collectionID) {
+		return fmt.Errorf("%w: item belongs to collection %s", ErrInvalidCollection, ci.CollectionID.OrdinalString())
+	} else if ci.MintNumber > quantity {
+		return fmt.Errorf("%w: mint number %d exceeds quantity %d", ErrInvalidCollection, ci.MintNumber, quantity)
+	}
+	return nil
+}
+
+// Map validates the item and returns its MAP metadata, to lock with
+// LockWithMapMetadata
+func (ci *CollectionItem) Map() (*bitcom.Map, error) {
+	if err := ci.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&collectionItemData{
+		CollectionID: ci.CollectionID.OrdinalString(),
+		MintNumber:   ci.MintNumber,
+		Rank:         ci.Rank,
+		RarityLabel:  ci.RarityLabel,
+		Traits:       ci.Traits,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordMap(ci.App, ci.Name, SubTypeCollectionItem, data), nil
+}
+
+// DecodeCollectionItem decodes collection item metadata from MAP metadata.
+// Returns ErrNotCollection if the metadata is not for a collection item. The
+// item is not validated; use Validate.
+func DecodeCollectionItem(m *bitcom.Map) (*CollectionItem, error) {
+	var data collectionItemData
+	app, name, err := decodeOrdMap(m, SubTypeCollectionItem, &data)
+	if err != nil {
+		return nil, err
+	}
+	item := &CollectionItem{
+		App:         app,
+		Name:        name,
+		MintNumber:  data.MintNumber,
+		Rank:        data.Rank,
+		RarityLabel: data.RarityLabel,
+		Traits:      data.Traits,
+	}
+	if data.CollectionID != "" {
+		if item.CollectionID, err = transaction.OutpointFromString(data.CollectionID); err != nil {
+			return nil, fmt.Errorf("%w: collection id %q", ErrInvalidSubTypeData, data.CollectionID)
+		}
+	}
+	return item, nil
+}
+
+// Collection decodes the collection metadata of the output. Returns
+// ErrNotCollection if it has none.
+func (op *OrdP2PKH) Collection() (*Collection, error) {
+	return DecodeCollection(op.Metadata)
+}
+
+// CollectionItem decodes the collection item metadata of the output. Returns
+// ErrNotCollection if it has none.
+func (op *OrdP2PKH) CollectionItem() (*CollectionItem, error) {
+	return DecodeCollectionItem(op.Metadata)
+}
+
+// ordMap returns the MAP SET command for 1Sat metadata
+func ordMap(app, name, subType string, subTypeData []byte) *bitcom.Map {
+	m := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	m.Set(MapKeyApp, app)
+	m.Set(MapKeyType, MapTypeOrd)
+	m.Set(MapKeyName, name)
+	m.Set(MapKeySubType, subType)
+	m.Set(MapKeySubTypeData, string(subTypeData))
+	return m
+}
+
+// decodeOrdMap checks m is 1Sat metadata of subType and decodes its subTypeData
+// into data, returning the app and name
+func decodeOrdMap(m *bitcom.Map, subType string, data any) (string, string, error) {
+	if m == nil {
+		return "", "", ErrNotCollection
+	}
+	if t, _ := m.Get(MapKeyType); t != MapTypeOrd {
+		return "", "", ErrNotCollection
+	}
+	if st, _ := m.Get(MapKeySubType); st != subType {
+		return "", "", fmt.Errorf("%w: subType is not %s", ErrNotCollection, subType)
+	}
+	if raw, ok := m.Get(MapKeySubTypeData); ok {
+		if err := json.Unmarshal([]byte(raw), data); err != nil {
+			return "", "", fmt.Errorf("%w: %w", ErrInvalidSubTypeData, err)
+		}
+	}
+	app, _ := m.Get(MapKeyApp)
+	name, _ := m.Get(MapKeyName)
+	return app, name, nil
+}
+
+// validateRarityLabels checks each label is named and the percentages total no more than 100%
+func validateRarityLabels(labels []RarityLabel) error {
+	percentages := make([]string, len(labels))
+	for i, l := range labels {
+		if l.Label == "" {
+			return fmt.Errorf("%w: empty rarity label", ErrInvalidCollection)
+		}
+		percentages[i] = l.Percentage
+	}
+	if _, err := sumPercentages(percentages); err != nil {
+		return fmt.Errorf("%w: rarity labels: %w", ErrInvalidCollection, err)
+	}
+	return nil
+}
+
+// sumPercentages parses and adds percentages, which must total no more than 100%
+func sumPercentages(percentages []string) (float64, error) {
+	var total float64
+	for _, p := range percentages {
+		v, err := parsePercentage(p)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	// Allow for rounding, e.g. three shares of 33.33%
+	if total > 1.0001 {
+		return total, fmt.Errorf("percentages total %s", formatPercentage(total))
+	}
+	return total, nil
+}
+
+// parsePercentage parses a percentage as a fraction of 1. Percentages are
+// written either with a percent sign, "5%", or as a fraction, "0.05".
+func parsePercentage(s string) (float64, error) {
+	trimmed := strings.TrimSpace(s)
+	percent := strings.HasSuffix(trimmed, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, "%"), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	if percent {
+		v /= 100
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("percentage %q out of range", s)
+	}
+	return v, nil
+}
+
+// formatPercentage formats a fraction of 1 as a percentage
+func formatPercentage(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', -1, 64) + "%"
+}
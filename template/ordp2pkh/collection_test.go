@@ -0,0 +1,172 @@
+package ordp2pkh
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitcoin-sv/go-templates/template/bitcom"
+	"github.com/bitcoin-sv/go-templates/template/inscription"
+	"github.com/bitcoin-sv/go-templates/template/internal/testutil"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// testCollection returns a valid collection
+func testCollection() *Collection {
+	return &Collection{
+		App:         "minter",
+		Name:        "Pixel Foxes",
+		Description: "Foxes, in pixels",
+		Quantity:    100,
+		RarityLabels: []RarityLabel{
+			{Label: "Common", Percentage: "90%"},
+			{Label: "Legendary", Percentage: "10%"},
+		},
+		Traits: map[string]*CollectionTrait{
+			"fur": {Values: []string{"red", "arctic"}, OccurrencePercentages: []string{"0.75", "0.25"}},
+		},
+		PreviewURL: "https://example.com/foxes.png",
+		Royalties:  []*Royalty{{Type: RoyaltyPaymail, Destination: "artist@handcash.io", Percentage: "0.05"}},
+	}
+}
+
+// TestCollection_RoundTrip verifies that collection metadata locked with
+// LockWithMapMetadata decodes back to the same collection
+func TestCollection_RoundTrip(t *testing.T) {
+	collection := testCollection()
+	m, err := collection.Map()
+	require.NoError(t, err)
+
+	// The subTypeData uses the 1Sat field names
+	raw, ok := m.Get(MapKeySubTypeData)
+	require.True(t, ok)
+	var data map[string]any
+	require.NoError(t, json.Unmarshal([]byte(raw), &data))
+	require.Equal(t, []any{map[string]any{"Common": "90%"}, map[string]any{"Legendary": "10%"}}, data["rarityLabels"])
+	require.Contains(t, raw, `"occurancePercentages":["0.75","0.25"]`)
+
+	// Royalties and the preview URL are top-level keys beside subTypeData
+	require.NotContains(t, data, "royalties")
+	require.NotContains(t, data, "previewUrl")
+	royalties, ok := m.Get(MapKeyRoyalties)
+	require.True(t, ok)
+	require.JSONEq(t, `[{"type":"paymail","destination":"artist@handcash.io","percentage":"0.05"}]`, royalties)
+	previewURL, _ := m.Get(MapKeyPreviewURL)
+	require.Equal(t, collection.PreviewURL, previewURL)
+
+	_, address := testutil.NewKey(t)
+	s, err := LockWithAddress(address, &inscription.Inscription{
+		File: inscription.File{Type: "image/png", Content: []byte("collection cover")},
+	}, m)
+	require.NoError(t, err)
+
+	decoded := Decode(s)
+	require.NotNil(t, decoded)
+	got, err := decoded.Collection()
+	require.NoError(t, err)
+	require.Equal(t, collection, got)
+	require.NoError(t, got.Validate())
+
+	_, err = decoded.CollectionItem()
+	require.ErrorIs(t, err, ErrNotCollection, "A collection is not a collection item")
+
+	m.Set(MapKeyRoyalties, "not json")
+	_, err = DecodeCollection(m)
+	require.ErrorIs(t, err, ErrInvalidCollection)
+}
+
+// TestCollectionItem_RoundTrip verifies that collection item metadata locked with
+// LockWithMapMetadata decodes back to the same item
+func TestCollectionItem_RoundTrip(t *testing.T) {
+	collectionID, err := transaction.OutpointFromString("4b7f0a8f2bde3b2f8a9e2d4c1a0b6e5d3c2b1a09f8e7d6c5b4a39281706f5e4d_0")
+	require.NoError(t, err)
+	item := &CollectionItem{
+		App:          "minter",
+		Name:         "Pixel Fox #7",
+		CollectionID: collectionID,
+		MintNumber:   7,
+		Rank:         3,
+		RarityLabel:  []RarityLabel{{Label: "Legendary", Percentage: "10%"}},
+		Traits:       []*CollectionItemTrait{{Name: "fur", Value: "arctic", RarityLabel: "Legendary", OccurrencePercentage: "25%"}},
+	}
+	m, err := item.Map()
+	require.NoError(t, err)
+	raw, _ := m.Get(MapKeySubTypeData)
+	require.Contains(t, raw, `"collectionId":"`+collectionID.OrdinalString()+`"`)
+
+	_, address := testutil.NewKey(t)
+	s, err := LockWithAddress(address, &inscription.Inscription{
+		File: inscription.File{Type: "image/png", Content: []byte("fox 7")},
+	}, m)
+	require.NoError(t, err)
+
+	decoded := Decode(s)
+	require.NotNil(t, decoded)
+	got, err := decoded.CollectionItem()
+	require.NoError(t, err)
+	require.Equal(t, item, got)
+	require.NoError(t, got.ValidateFor(collectionID, 100))
+	require.ErrorIs(t, got.ValidateFor(collectionID, 5), ErrInvalidCollection, "Mint number exceeds quantity")
+	require.ErrorIs(t, got.ValidateFor(&transaction.Outpoint{}, 100), ErrInvalidCollection, "Different collection")
+}
+
+// TestCollection_Validate verifies that invalid collections cannot be encoded
+func TestCollection_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Collection)
+	}{
+		{"missing app", func(c *Collection) { c.App = "" }},
+		{"missing name", func(c *Collection) { c.Name = "" }},
+		{"zero quantity", func(c *Collection) { c.Quantity = 0 }},
+		{"empty rarity label", func(c *Collection) { c.RarityLabels[0].Label = "" }},
+		{"rarity over 100%", func(c *Collection) { c.RarityLabels[0].Percentage = "95%" }},
+		{"invalid percentage", func(c *Collection) { c.RarityLabels[0].Percentage = "most" }},
+		{"trait occurrences mismatch", func(c *Collection) { c.Traits["fur"].OccurrencePercentages = []string{"1"} }},
+		{"unknown royalty type", func(c *Collection) { c.Royalties[0].Type = "bank" }},
+		{"royalty missing destination", func(c *Collection) { c.Royalties[0].Destination = "" }},
+		{"royalties take the sale", func(c *Collection) {
+			c.Royalties = append(c.Royalties, &Royalty{Type: RoyaltyAddress, Destination: "1BitcoinEaterAddressDontSendf59kuE", Percentage: "95%"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCollection()
+			tt.modify(c)
+			require.ErrorIs(t, c.Validate(), ErrInvalidCollection)
+			_, err := c.Map()
+			require.ErrorIs(t, err, ErrInvalidCollection)
+		})
+	}
+
+	// Percentages may be written as fractions or with a percent sign, and may round
+	c := testCollection()
+	c.RarityLabels = []RarityLabel{{"a", "33.34%"}, {"b", "0.3333"}, {"c", "33.33%"}}
+	require.NoError(t, c.Validate())
+
+	require.ErrorIs(t, (&CollectionItem{App: "minter", Name: "orphan"}).Validate(), ErrInvalidCollection)
+}
+
+// TestDecodeCollection_Invalid verifies that metadata that is not a collection is rejected
+func TestDecodeCollection_Invalid(t *testing.T) {
+	_, err := DecodeCollection(nil)
+	require.ErrorIs(t, err, ErrNotCollection)
+
+	m := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	m.Set(MapKeyApp, "minter")
+	m.Set(MapKeyType, "post")
+	_, err = DecodeCollection(m)
+	require.ErrorIs(t, err, ErrNotCollection)
+
+	m = ordMap("minter", "broken", SubTypeCollection, []byte(`{"quantity":"lots"}`))
+	_, err = DecodeCollection(m)
+	require.ErrorIs(t, err, ErrInvalidSubTypeData)
+
+	m = ordMap("minter", "broken", SubTypeCollectionItem, []byte(`{"collectionId":"nope"}`))
+	_, err = DecodeCollectionItem(m)
+	require.ErrorIs(t, err, ErrInvalidSubTypeData)
+
+	m = ordMap("minter", "broken", SubTypeCollection, []byte(`{"rarityLabels":[{"a":"1%","b":"2%"}]}`))
+	_, err = DecodeCollection(m)
+	require.ErrorIs(t, err, ErrInvalidSubTypeData)
+}
@@ -0,0 +1,222 @@
+package ordp2pkh
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitcoin-sv/go-templates/template/inscription"
+	"github.com/bitcoin-sv/go-templates/template/p2pkh"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	feemodel "github.com/bsv-blockchain/go-sdk/transaction/fee_model"
+)
+
+var (
+	ErrNoMintItems      = errors.New("no items to mint")
+	ErrMintMissingField = errors.New("collection mint is missing a required field")
+	ErrTooManyItems     = errors.New("items exceed the collection quantity")
+	ErrItemTooLarge     = errors.New("item exceeds the maximum fee of a transaction")
+	ErrMintFunding      = errors.New("insufficient funding to mint items")
+	ErrParentSatoshis   = errors.New("collection parent must hold a single satoshi")
+)
+
+// MintItem is an item to inscribe into a collection
+type MintItem struct {
+	File     inscription.File
+	Metadata *CollectionItem // App, Name, CollectionID and MintNumber are filled in if unset
+	Address  *script.Address // Receives the item, CollectionMint.Address if nil
+}
+
+// CollectionMint builds the transactions that mint the items of a collection.
+// Items are batched into transactions whose fee does not exceed MaxFee. Each
+// transaction spends the collection inscription and returns it in its first
+// output, so the items' parent references are provable, followed by an output
+// for each item and a change output that funds the next transaction:
+//
+//	inputs:  collection, funding (change of the previous transaction)
+//	outputs: collection, item, item, ..., change
+//
+// Satoshis move from inputs to outputs in order, so the collection's satoshi
+// stays in the first output and each item is inscribed on a new satoshi.
+type CollectionMint struct {
+	Collection   *Collection
+	CollectionID *transaction.Outpoint // Origin of the collection inscription, the items' parent
+	Parent       *transaction.UTXO     // 1 satoshi output holding the collection inscription, with an unlocking template
+	Funding      []*transaction.UTXO   // Outputs paying the fees, with unlocking templates
+
+	Address        *script.Address                     // Receives the items, the collection and the change
+	Unlocker       transaction.UnlockingScriptTemplate // Unlocks outputs paid to Address
+	ChangeAddress  *script.Address                     // Receives the change instead of Address if set
+	ChangeUnlocker transaction.UnlockingScriptTemplate // Unlocks outputs paid to ChangeAddress
+
+	FeeModel  transaction.FeeModel // Defaults to 1 satoshi per kilobyte
+	MaxFee    uint64               // Maximum fee of each transaction, unlimited if 0
+	MaxItems  int                  // Maximum items in each transaction, unlimited if 0
+	FirstMint uint64               // Mint number of the first item, 1 if 0
+}
+
+// Build validates the collection and items, then builds and signs the minting
+// transactions, returned in the order they must be broadcast
+func (m *CollectionMint) Build(items []*MintItem) ([]*transaction.Transaction, error) {
+	if err := m.validate(items); err != nil {
+		return nil, err
+	}
+	feeModel := m.FeeModel
+	if feeModel == nil {
+		feeModel = &feemodel.SatoshisPerKilobyte{Satoshis: 1}
+	}
+	changeAddress, changeUnlocker := m.ChangeAddress, m.ChangeUnlocker
+	if changeAddress == nil {
+		changeAddress, changeUnlocker = m.Address, m.Unlocker
+	}
+	collectionScript, err := p2pkh.Lock(m.Address)
+	if err != nil {
+		return nil, err
+	}
+	changeScript, err := p2pkh.Lock(changeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := m.itemScripts(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []*transaction.Transaction
+	for next := 0; next < len(locks); {
+		tx := transaction.NewTransaction()
+		if prev := len(txs) - 1; prev < 0 {
+			_ = tx.AddInputsFromUTXOs(m.Parent)
+			_ = tx.AddInputsFromUTXOs(m.Funding...)
+		} else {
+			tx.AddInputFromTx(txs[prev], 0, m.Unlocker)
+			tx.AddInputFromTx(txs[prev], uint32(len(txs[prev].Outputs)-1), changeUnlocker)
+		}
+		tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: collectionScript})
+		change := &transaction.TransactionOutput{LockingScript: changeScript, Change: true}
+
+		// Add items until the fee or item limit is reached
+		batch := 0
+		for ; next < len(locks) && (m.MaxItems == 0 || batch < m.MaxItems); next, batch = next+1, batch+1 {
+			tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: locks[next]})
+			tx.Outputs = append(tx.Outputs, change)
+			fee, err := feeModel.ComputeFee(tx)
+			tx.Outputs = tx.Outputs[:len(tx.Outputs)-1]
+			if err != nil {
+				return nil, err
+			}
+			if m.MaxFee > 0 && fee > m.MaxFee {
+				tx.Outputs = tx.Outputs[:len(tx.Outputs)-1]
+				if batch == 0 {
+					return nil, fmt.Errorf("%w: item %d needs a fee of %d", ErrItemTooLarge, next, fee)
+				}
+				break
+			}
+		}
+
+		tx.AddOutput(change)
+		if err := tx.Fee(feeModel, transaction.ChangeDistributionEqual); err != nil {
+			return nil, fmt.Errorf("%w: transaction %d: %w", ErrMintFunding, len(txs), err)
+		}
+		if next < len(locks) && len(tx.Outputs) == batch+1 {
+			// The change was too small to keep, leaving nothing to fund the next transaction
+			return nil, fmt.Errorf("%w: no change left after transaction %d", ErrMintFunding, len(txs))
+		}
+		if err := tx.Sign(); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// validate checks the mint has what it needs to build the transactions
+func (m *CollectionMint) validate(items []*MintItem) error {
+	switch {
+	case len(items) == 0:
+		return ErrNoMintItems
+	case m.Collection == nil:
+		return fmt.Errorf("%w: Collection", ErrMintMissingField)
+	case m.CollectionID == nil:
+		return fmt.Errorf("%w: CollectionID", ErrMintMissingField)
+	case m.Parent == nil || m.Parent.UnlockingScriptTemplate == nil:
+		return fmt.Errorf("%w: Parent with an unlocking template", ErrMintMissingField)
+	case len(m.Funding) == 0:
+		return fmt.Errorf("%w: Funding", ErrMintMissingField)
+	case m.Address == nil || m.Unlocker == nil:
+		return fmt.Errorf("%w: Address and Unlocker", ErrMintMissingField)
+	case m.ChangeAddress != nil && m.ChangeUnlocker == nil:
+		return fmt.Errorf("%w: ChangeUnlocker", ErrMintMissingField)
+	case m.Parent.Satoshis != 1:
+		return fmt.Errorf("%w: holds %d", ErrParentSatoshis, m.Parent.Satoshis)
+	}
+	for i, item := range items {
+		if item == nil {
+			return fmt.Errorf("%w: item %d", ErrMintMissingField, i)
+		}
+	}
+	for _, utxo := range m.Funding {
+		if utxo.UnlockingScriptTemplate == nil {
+			return fmt.Errorf("%w: Funding with unlocking templates", ErrMintMissingField)
+		}
+	}
+	if err := m.Collection.Validate(); err != nil {
+		return err
+	}
+	if last := m.firstMint() + uint64(len(items)) - 1; last > m.Collection.Quantity {
+		return fmt.Errorf("%w: minting up to %d of %d", ErrTooManyItems, last, m.Collection.Quantity)
+	}
+	return nil
+}
+
+// firstMint returns the mint number of the first item
+func (m *CollectionMint) firstMint() uint64 {
+	if m.FirstMint == 0 {
+		return 1
+	}
+	return m.FirstMint
+}
+
+// itemScripts returns the locking script of each item, inscribed with the
+// collection as its parent and with its collection item metadata
+func (m *CollectionMint) itemScripts(items []*MintItem) ([]*script.Script, error) {
+	locks := make([]*script.Script, len(items))
+	for i, item := range items {
+		metadata := &CollectionItem{}
+		if item.Metadata != nil {
+			*metadata = *item.Metadata
+		}
+		if metadata.App == "" {
+			metadata.App = m.Collection.App
+		}
+		if metadata.CollectionID == nil {
+			metadata.CollectionID = m.CollectionID
+		}
+		if metadata.MintNumber == 0 {
+			metadata.MintNumber = m.firstMint() + uint64(i)
+		}
+		if metadata.Name == "" {
+			metadata.Name = fmt.Sprintf("%s #%d", m.Collection.Name, metadata.MintNumber)
+		}
+		if err := metadata.ValidateFor(m.CollectionID, m.Collection.Quantity); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		mapData, err := metadata.Map()
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		address := item.Address
+		if address == nil {
+			address = m.Address
+		}
+		if locks[i], err = LockWithAddress(address, &inscription.Inscription{
+			File:   item.File,
+			Parent: m.CollectionID,
+		}, mapData); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return locks, nil
+}
@@ -0,0 +1,273 @@
+package ordp2pkh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bitcoin-sv/go-templates/lib"
+	"github.com/bitcoin-sv/go-templates/template/inscription"
+	"github.com/bitcoin-sv/go-templates/template/internal/testutil"
+	"github.com/bitcoin-sv/go-templates/template/p2pkh"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	feemodel "github.com/bsv-blockchain/go-sdk/transaction/fee_model"
+	"github.com/stretchr/testify/require"
+)
+
+// mintFixture holds a collection inscribed in a genesis transaction, ready to mint
+type mintFixture struct {
+	genesis *transaction.Transaction
+	mint    *CollectionMint
+}
+
+// newMintFixture inscribes a collection and returns a mint spending it, funded with satoshis
+func newMintFixture(t *testing.T, satoshis uint64) *mintFixture {
+	t.Helper()
+	key, address := testutil.NewKey(t)
+	unlocker, err := p2pkh.Unlock(key, nil)
+	require.NoError(t, err)
+	payScript, err := p2pkh.Lock(address)
+	require.NoError(t, err)
+
+	collection := testCollection()
+	metadata, err := collection.Map()
+	require.NoError(t, err)
+	collectionScript, err := LockWithAddress(address, &inscription.Inscription{
+		File: inscription.File{Type: "image/png", Content: []byte("cover")},
+	}, metadata)
+	require.NoError(t, err)
+
+	// Genesis inscribes the collection and funds the mint
+	genesis := testutil.NewGenesis(
+		&transaction.TransactionOutput{Satoshis: 1, LockingScript: collectionScript},
+		&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: payScript},
+	)
+
+	return &mintFixture{
+		genesis: genesis,
+		mint: &CollectionMint{
+			Collection:   collection,
+			CollectionID: &transaction.Outpoint{Txid: *genesis.TxID(), Index: 0},
+			Parent: &transaction.UTXO{
+				TxID: genesis.TxID(), Vout: 0, LockingScript: collectionScript, Satoshis: 1,
+				UnlockingScriptTemplate: unlocker,
+			},
+			Funding: []*transaction.UTXO{{
+				TxID: genesis.TxID(), Vout: 1, LockingScript: payScript, Satoshis: satoshis,
+				UnlockingScriptTemplate: unlocker,
+			}},
+			Address:  address,
+			Unlocker: unlocker,
+		},
+	}
+}
+
+// mintItems returns n items with content of the given size
+func mintItems(n, size int) []*MintItem {
+	items := make([]*MintItem, n)
+	for i := range items {
+		content := bytes.Repeat([]byte{byte(i)}, size)
+		items[i] = &MintItem{
+			File: inscription.File{Type: "image/png", Content: content},
+			Metadata: &CollectionItem{
+				Traits: []*CollectionItemTrait{{Name: "fur", Value: fmt.Sprintf("shade %d", i)}},
+			},
+		}
+	}
+	return items
+}
+
+// TestCollectionMint_Build verifies that items are batched into a chain of signed
+// transactions, each spending the collection and the previous change
+func TestCollectionMint_Build(t *testing.T) {
+	f := newMintFixture(t, 100000)
+	f.mint.MaxFee = 5
+	items := mintItems(7, 1500)
+
+	txs, err := f.mint.Build(items)
+	require.NoError(t, err)
+	require.Greater(t, len(txs), 1, "Items should be split across transactions")
+
+	mint := uint64(0)
+	for i, tx := range txs {
+		// Each transaction spends the collection and the funding from the one before
+		require.Len(t, tx.Inputs, 2)
+		if i == 0 {
+			require.Equal(t, f.mint.CollectionID.String(), (&transaction.Outpoint{Txid: *tx.Inputs[0].SourceTXID, Index: tx.Inputs[0].SourceTxOutIndex}).String())
+		} else {
+			require.Equal(t, txs[i-1].TxID().String(), tx.Inputs[0].SourceTXID.String())
+			require.Equal(t, uint32(0), tx.Inputs[0].SourceTxOutIndex)
+			require.Equal(t, uint32(len(txs[i-1].Outputs)-1), tx.Inputs[1].SourceTxOutIndex)
+		}
+
+		fee, err := tx.GetFee()
+		require.NoError(t, err)
+		require.LessOrEqual(t, fee, f.mint.MaxFee)
+		testutil.RequireValid(t, tx)
+
+		// The collection comes first, the change last and the items in between
+		require.Equal(t, uint64(1), tx.Outputs[0].Satoshis)
+		require.Nil(t, inscription.Decode(tx.Outputs[0].LockingScript), "The collection is not reinscribed")
+		require.True(t, tx.Outputs[len(tx.Outputs)-1].Change)
+		for _, out := range tx.Outputs[1 : len(tx.Outputs)-1] {
+			mint++
+			require.Equal(t, uint64(1), out.Satoshis)
+			decoded := Decode(out.LockingScript)
+			require.NotNil(t, decoded)
+			require.Equal(t, f.mint.CollectionID.String(), decoded.Inscription.Parent.String())
+			require.Equal(t, items[mint-1].File.Content, decoded.Inscription.File.Content)
+
+			item, err := decoded.CollectionItem()
+			require.NoError(t, err)
+			require.Equal(t, mint, item.MintNumber)
+			require.Equal(t, fmt.Sprintf("Pixel Foxes #%d", mint), item.Name)
+			require.Equal(t, "minter", item.App)
+			require.NoError(t, item.ValidateFor(f.mint.CollectionID, f.mint.Collection.Quantity))
+		}
+	}
+	require.Equal(t, uint64(len(items)), mint)
+	require.Nil(t, items[0].Metadata.CollectionID, "Items passed in are not modified")
+
+	// The collection keeps its origin through the chain, and each item is a new origin
+	src := lib.NewMemoryTxSource(append(txs, f.genesis)...)
+	tracker := inscription.NewOriginTracker(src)
+	last := txs[len(txs)-1]
+	origin, err := tracker.Trace(context.Background(), &transaction.Outpoint{Txid: *last.TxID(), Index: 0})
+	require.NoError(t, err)
+	require.Equal(t, f.mint.CollectionID.String(), origin.Outpoint.String())
+	itemOutpoint := &transaction.Outpoint{Txid: *last.TxID(), Index: 1}
+	origin, err = tracker.Trace(context.Background(), itemOutpoint)
+	require.NoError(t, err)
+	require.Equal(t, itemOutpoint.String(), origin.Outpoint.String())
+}
+
+// TestCollectionMint_Spend verifies that the collection, inscribed with its MAP
+// metadata, and the minted items remain spendable
+func TestCollectionMint_Spend(t *testing.T) {
+	f := newMintFixture(t, 100000)
+	collection, err := Decode(f.genesis.Outputs[0].LockingScript).Collection()
+	require.NoError(t, err)
+	require.Equal(t, f.mint.Collection.Name, collection.Name)
+
+	txs, err := f.mint.Build(mintItems(2, 10))
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	testutil.RequireValid(t, txs[0])
+
+	// Spend the re-locked collection and an item, whose MAP follows its P2PKH lock
+	require.NotNil(t, Decode(txs[0].Outputs[1].LockingScript).Metadata)
+	_, toAddress := testutil.NewKey(t)
+	toScript, err := p2pkh.Lock(toAddress)
+	require.NoError(t, err)
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(txs[0], 0, f.mint.Unlocker)
+	tx.AddInputFromTx(txs[0], 1, f.mint.Unlocker)
+	tx.AddInputFromTx(txs[0], uint32(len(txs[0].Outputs)-1), f.mint.Unlocker)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: toScript})
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: toScript})
+	tx.AddOutput(&transaction.TransactionOutput{LockingScript: toScript, Change: true})
+	require.NoError(t, tx.Fee(&feemodel.SatoshisPerKilobyte{Satoshis: 1}, transaction.ChangeDistributionEqual))
+	require.NoError(t, tx.Sign())
+	testutil.RequireValid(t, tx)
+
+	// Another key cannot spend the item
+	otherKey, _ := testutil.NewKey(t)
+	other, err := p2pkh.Unlock(otherKey, nil)
+	require.NoError(t, err)
+	tx.Inputs[1].UnlockingScript, err = other.Sign(tx, 1)
+	require.NoError(t, err)
+	require.Error(t, testutil.ExecuteInput(tx, 1))
+}
+
+// TestCollectionMint_Limits verifies the item and fee limits, funding checks and
+// required fields
+func TestCollectionMint_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(m *CollectionMint)
+		items   []*MintItem
+		wantTxs int
+		wantErr error
+	}{
+		{
+			name:    "item limit splits transactions",
+			setup:   func(m *CollectionMint) { m.MaxItems = 2 },
+			items:   mintItems(5, 10),
+			wantTxs: 3,
+		},
+		{
+			name:    "unlimited fits one transaction",
+			items:   mintItems(5, 10),
+			wantTxs: 1,
+		},
+		{
+			name:    "item over the maximum fee",
+			setup:   func(m *CollectionMint) { m.MaxFee = 1 },
+			items:   mintItems(1, 2000),
+			wantErr: ErrItemTooLarge,
+		},
+		{
+			name:    "more items than the quantity",
+			items:   mintItems(int(testCollection().Quantity)+1, 1),
+			wantErr: ErrTooManyItems,
+		},
+		{
+			name:    "first mint near the quantity",
+			setup:   func(m *CollectionMint) { m.FirstMint = 100 },
+			items:   mintItems(2, 1),
+			wantErr: ErrTooManyItems,
+		},
+		{
+			name:    "no items",
+			wantErr: ErrNoMintItems,
+		},
+		{
+			name: "insufficient funding",
+			setup: func(m *CollectionMint) {
+				m.MaxItems = 1
+				m.Funding[0].Satoshis = 3
+			},
+			items:   mintItems(3, 10),
+			wantErr: ErrMintFunding,
+		},
+		{
+			name:    "missing unlocker",
+			setup:   func(m *CollectionMint) { m.Unlocker = nil },
+			items:   mintItems(1, 1),
+			wantErr: ErrMintMissingField,
+		},
+		{
+			name:    "nil item",
+			items:   append(mintItems(1, 1), nil),
+			wantErr: ErrMintMissingField,
+		},
+		{
+			name:    "parent holding more than one satoshi",
+			setup:   func(m *CollectionMint) { m.Parent.Satoshis = 2 },
+			items:   mintItems(1, 1),
+			wantErr: ErrParentSatoshis,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMintFixture(t, 100000)
+			if tt.setup != nil {
+				tt.setup(f.mint)
+			}
+			txs, err := f.mint.Build(tt.items)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, txs, tt.wantTxs)
+			minted := 0
+			for _, tx := range txs {
+				minted += len(tx.Outputs) - 2
+			}
+			require.Equal(t, len(tt.items), minted, "Every item should be minted once")
+		})
+	}
+}
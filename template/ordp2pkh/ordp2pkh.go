@@ -57,17 +57,9 @@ func getMetadataFromScript(s *script.Script) *bitcom.Map {
 		return nil
 	}
 
-	// Use bitcom.Decode to find all BitCom protocols in the script
-	bc := bitcom.Decode(s)
-	if bc == nil || len(bc.Protocols) == 0 {
-		return nil
-	}
-
-	// Look for MAP protocol
-	for _, proto := range bc.Protocols {
-		if proto.Protocol == bitcom.MapPrefix {
-			return bitcom.DecodeMap(proto.Script)
-		}
+	// Decode all BitCom protocols in the script and pick the first MAP
+	if m, ok := bitcom.FirstValue[*bitcom.Map](bitcom.DecodeAll(s)); ok {
+		return m
 	}
 
 	return nil
@@ -178,24 +170,27 @@ func (op *OrdP2PKH) LockWithMapMetadata(metadata *bitcom.Map) (*script.Script, e
 	}
 
 	// Validate MAP metadata - must have app and type fields
-	if _, hasApp := metadata.Data["app"]; !hasApp {
+	if _, hasApp := metadata.Get("app"); !hasApp {
 		return combinedScript, nil // Return without MAP if app is missing
 	}
 
-	if _, hasType := metadata.Data["type"]; !hasType {
+	if _, hasType := metadata.Get("type"); !hasType {
 		return combinedScript, nil // Return without MAP if type is missing
 	}
 
-	// Create a standalone MAP script
+	// Create a standalone MAP script. The command is written as is, and the pairs
+	// follow the metadata's pair order so identical metadata always produces
+	// identical script bytes. A plain OP_RETURN ends execution after the P2PKH
+	// lock has run, as 1Sat ordinals do, so the output stays spendable.
 	mapScript := &script.Script{}
-	_ = mapScript.AppendOpcodes(script.OpFALSE, script.OpRETURN)
+	_ = mapScript.AppendOpcodes(script.OpRETURN)
 	_ = mapScript.AppendPushDataString(bitcom.MapPrefix)
 	_ = mapScript.AppendPushDataString(string(metadata.Cmd))
 
 	// Add all key-value pairs
-	for key, value := range metadata.Data {
-		_ = mapScript.AppendPushDataString(key)
-		_ = mapScript.AppendPushDataString(value)
+	for _, pair := range metadata.OrderedPairs() {
+		_ = mapScript.AppendPushDataString(pair.Key)
+		_ = mapScript.AppendPushDataString(pair.Value)
 	}
 
 	// Return the combined script with MAP script appended
@@ -1,6 +1,7 @@
 package ordp2pkh
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -620,3 +621,131 @@ func getAddressFromScriptRobust(inscription *inscription.Inscription) *script.Ad
 
 	return nil
 }
+
+// TestLockWithMapMetadataDeterministic verifies that identical metadata always
+// produces identical script bytes and that pair order is preserved
+func TestLockWithMapMetadataDeterministic(t *testing.T) {
+	privKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(privKey.PubKey(), true)
+	require.NoError(t, err)
+
+	newInscription := func() *inscription.Inscription {
+		return &inscription.Inscription{
+			File: inscription.File{
+				Type:    "text/plain",
+				Content: []byte("deterministic"),
+			},
+		}
+	}
+
+	// Metadata built from a Go map is encoded in sorted key order
+	metadata := &bitcom.Map{
+		Cmd: bitcom.MapCmdSet,
+		Data: map[string]string{
+			"app":  "test-nft-app",
+			"type": "nft",
+			"name": "Test NFT",
+			"a":    "1",
+			"z":    "26",
+		},
+	}
+	first, err := LockWithAddress(address, newInscription(), metadata)
+	require.NoError(t, err)
+	for range 20 {
+		again, err := LockWithAddress(address, newInscription(), metadata)
+		require.NoError(t, err)
+		require.Equal(t, first.Bytes(), again.Bytes(), "Script bytes should be deterministic")
+	}
+
+	// Metadata built with Set keeps its insertion order, including repeated keys
+	ordered := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	ordered.Set("type", "nft")
+	ordered.Set("app", "test-nft-app")
+	ordered.Set("trait", "blue")
+	ordered.Set("trait", "rare")
+	combined, err := LockWithAddress(address, newInscription(), ordered)
+	require.NoError(t, err)
+
+	decoded := Decode(combined)
+	require.NotNil(t, decoded)
+	require.NotNil(t, decoded.Metadata)
+	require.Equal(t, ordered.Pairs, decoded.Metadata.Pairs)
+	require.Equal(t, []string{"blue", "rare"}, decoded.Metadata.Values("trait"))
+}
+
+// TestLockWithMapMetadataCmd verifies that the MAP command is written as given,
+// including an empty command, without returning an error
+func TestLockWithMapMetadataCmd(t *testing.T) {
+	privKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(privKey.PubKey(), true)
+	require.NoError(t, err)
+
+	for _, cmd := range []bitcom.MapCmd{"", "CUSTOM"} {
+		metadata := &bitcom.Map{Cmd: cmd, Data: map[string]string{"app": "test-nft-app", "type": "nft"}}
+		combined, err := LockWithAddress(address, &inscription.Inscription{
+			File: inscription.File{Type: "text/plain", Content: []byte("command")},
+		}, metadata)
+		require.NoError(t, err)
+
+		decoded := bitcom.Decode(combined)
+		require.NotNil(t, decoded)
+		require.Len(t, decoded.Protocols, 1)
+		m := bitcom.DecodeMap(decoded.Protocols[0].Script)
+		require.NotNil(t, m)
+		require.Equal(t, cmd, m.Cmd)
+	}
+}
+
+// TestLockWithMapMetadataPlainReturn verifies that the MAP follows the inscription
+// and P2PKH lock after a plain OP_RETURN, and that Bitcom keeps that script whole
+// as its prefix
+func TestLockWithMapMetadataPlainReturn(t *testing.T) {
+	privKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	address, err := script.NewAddressFromPublicKey(privKey.PubKey(), true)
+	require.NoError(t, err)
+	newInscription := func() *inscription.Inscription {
+		return &inscription.Inscription{
+			File: inscription.File{Type: "text/plain", Content: []byte("plain return")},
+		}
+	}
+
+	lockScript, err := LockWithAddress(address, newInscription(), nil)
+	require.NoError(t, err)
+	metadata := &bitcom.Map{Cmd: bitcom.MapCmdSet}
+	metadata.Set("app", "test-nft-app")
+	metadata.Set("type", "nft")
+	combined, err := LockWithAddress(address, newInscription(), metadata)
+	require.NoError(t, err)
+
+	require.True(t, bytes.HasPrefix(combined.Bytes(), lockScript.Bytes()))
+	require.Equal(t, byte(script.OpRETURN), combined.Bytes()[len(*lockScript)], "No OP_FALSE precedes the OP_RETURN")
+
+	bc := bitcom.Decode(combined)
+	require.NotNil(t, bc)
+	require.Equal(t, lockScript.Bytes(), bc.ScriptPrefix)
+}
+
+// TestLockWithTestVector verifies that LockWithMapMetadata rebuilds the 1Sat
+// output in the test vector, whose MAP follows the P2PKH lock after a plain OP_RETURN
+func TestLockWithTestVector(t *testing.T) {
+	hexBytes, err := os.ReadFile(filepath.Join("testdata", "b08538c963d2b88c7d26600a1c3c925a3388e942cdc5f903ecf0009f18c41ff3.hex"))
+	require.NoError(t, err, "Failed to read test vector file")
+	tx, err := transaction.NewTransactionFromHex(strings.TrimSpace(string(hexBytes)))
+	require.NoError(t, err, "Failed to parse transaction")
+
+	vector := tx.Outputs[0].LockingScript
+	decoded := Decode(vector)
+	require.NotNil(t, decoded)
+	require.NotNil(t, decoded.Metadata)
+
+	// The vector signs its MAP with SIGMA, so the rebuilt script ends where the SIGMA protocol begins
+	lockScript, err := LockWithAddress(decoded.Address, &inscription.Inscription{File: decoded.Inscription.File}, decoded.Metadata)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(vector.Bytes(), lockScript.Bytes()), "Rebuilt script should prefix the vector")
+	pipe := &script.Script{}
+	require.NoError(t, pipe.AppendPushDataString("|"))
+	require.True(t, bytes.HasPrefix(vector.Bytes()[len(*lockScript):], pipe.Bytes()), "Only the SIGMA protocol should follow")
+}